@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"notpass-go/pkg/random"
+	"notpass-go/pkg/vault"
+	"notpass-go/pkg/vault/audit"
+)
+
+func auditEntries(opts options, args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	expiringWithin := fs.Int("expiring-within", 30, "report passwords that expire within this many days")
+	maxAge := fs.Int("max-age", 0, "report passwords that have not been changed in this many days (0 to disable)")
+	reused := fs.Bool("reused", false, "report passwords that are used by more than one entry")
+	minEntropy := fs.Float64("min-entropy", 0, "report passwords with less than this many bits of estimated entropy")
+	breaches := fs.String("breaches", "", "report passwords found in this Have I Been Pwned SHA-1 file (ordered by hash)")
+	format := fs.String("format", "", "deprecated: use the global -output option")
+	_ = fs.Parse(args)
+
+	if *format != "" {
+		if !validOutput(*format) {
+			return fmt.Errorf("invalid format: expected table, json or yaml")
+		}
+		_, _ = fmt.Fprintln(os.Stderr, "Warning: audit -format is deprecated; use pwsafe -output instead")
+		opts.output = *format
+	}
+
+	v, err := openVault(opts)
+	if err != nil {
+		return err
+	}
+	defer closeVault(v)
+
+	entries := v.List()
+	sortEntries(entries)
+
+	findings := audit.CheckPasswordAge(entries, audit.AgePolicy{
+		Now:           now(),
+		ExpiryWarning: time.Duration(*expiringWithin) * 24 * time.Hour,
+		MaxAge:        time.Duration(*maxAge) * 24 * time.Hour,
+	})
+
+	if *reused || *minEntropy > 0 || *breaches != "" {
+		var full []vault.Entry
+		for _, e := range entries {
+			if e, found := v.Get(e.Id()); found {
+				full = append(full, e)
+			}
+		}
+
+		if *reused {
+			findings = append(findings, audit.CheckReusedPasswords(full)...)
+		}
+		if *minEntropy > 0 {
+			findings = append(findings, audit.CheckWeakPasswords(full, *minEntropy, random.DefaultDictionary)...)
+		}
+		if *breaches != "" {
+			f, err := checkBreaches(full, *breaches)
+			if err != nil {
+				return err
+			}
+			findings = append(findings, f...)
+		}
+	}
+
+	if findings == nil {
+		findings = []audit.Finding{}
+	}
+	return writeOutput(opts, findings, func() error {
+		return printFindingsTable(findings)
+	})
+}
+
+func checkBreaches(entries []vault.Entry, corpusFile string) ([]audit.Finding, error) {
+	c, err := audit.OpenBreachCorpus(corpusFile)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := c.Close()
+		if err != nil {
+			log.Printf("error: closing breach corpus: %v", err)
+		}
+	}()
+	return audit.CheckBreachedPasswords(entries, c)
+}
+
+func printFindingsTable(findings []audit.Finding) error {
+	if len(findings) == 0 {
+		fmt.Println("No issues found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tENTRY\tISSUE\tDETAIL")
+	for _, f := range findings {
+		name := f.Name
+		if f.Group != "" {
+			name = f.Group + "/" + f.Name
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Id, name, f.Issue, f.Detail)
+	}
+	return w.Flush()
+}
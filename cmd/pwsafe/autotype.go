@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"notpass-go/pkg/sensitive"
+	"notpass-go/pkg/vault/autotype"
+)
+
+// autotypeStepJson is one keystroke step. Text is redacted unless -reveal is given, since it may
+// contain the password.
+type autotypeStepJson struct {
+	Kind     string `json:"kind"`
+	Text     string `json:"text,omitempty"`
+	Key      string `json:"key,omitempty"`
+	Duration string `json:"duration,omitempty"`
+}
+
+// showAutotype prints the keystrokes of an entry's autotype script, so that they can be fed to a
+// tool such as xdotool. pwsafe doesn't type them itself.
+func showAutotype(opts options, args []string) error {
+	fs := flag.NewFlagSet("autotype", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	v, err := openVault(opts)
+	if err != nil {
+		return err
+	}
+	defer closeVault(v)
+
+	e, found, err := selectEntry(opts, v, fs.Args())
+	if err != nil || !found {
+		return err
+	}
+
+	steps, err := autotype.Expand(e)
+	if err != nil {
+		return err
+	}
+
+	out := []autotypeStepJson{}
+	for _, s := range steps {
+		step := autotypeStepJson{Kind: s.Kind.String(), Key: s.Key}
+		switch s.Kind {
+		case autotype.Text:
+			step.Text = sensitive.Redacted
+			if opts.reveal {
+				step.Text = s.Text.AsString()
+			}
+		case autotype.Delay, autotype.Wait:
+			step.Duration = s.Duration.String()
+		}
+		out = append(out, step)
+	}
+
+	return writeOutput(opts, out, func() error {
+		for _, s := range out {
+			switch s.Kind {
+			case "text":
+				fmt.Printf("%s\t%s\n", s.Kind, s.Text)
+			case "key":
+				fmt.Printf("%s\t%s\n", s.Kind, s.Key)
+			default:
+				fmt.Printf("%s\t%s\n", s.Kind, s.Duration)
+			}
+		}
+		return nil
+	})
+}
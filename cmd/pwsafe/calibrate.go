@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"notpass-go/internal/backend/passwordsafe"
+)
+
+func calibrate(opts options, args []string) error {
+	fs := flag.NewFlagSet("calibrate", flag.ExitOnError)
+	target := fs.Duration("time", passwordsafe.DefaultUnlockTime, "how long unlocking a safe should take")
+	_ = fs.Parse(args)
+
+	if *target <= 0 {
+		return fmt.Errorf("invalid time: expected a positive duration")
+	}
+
+	out := calibrateJson{
+		Iterations: passwordsafe.CalibrateIterations(*target),
+		Time:       target.String(),
+	}
+	return writeOutput(opts, out, func() error {
+		fmt.Println(out.Iterations)
+		return nil
+	})
+}
+
+type calibrateJson struct {
+	Iterations uint   `json:"iterations"`
+	Time       string `json:"time"`
+}
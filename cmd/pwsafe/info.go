@@ -0,0 +1,180 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"notpass-go/internal/backend/passwordsafe"
+	"notpass-go/pkg/vault"
+)
+
+func showInfo(opts options, args []string) error {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	v, err := openVault(opts)
+	if err != nil {
+		return err
+	}
+	defer closeVault(v)
+
+	h := v.Header()
+	_, hasYubikey := v.YubikeySecret()
+
+	out := infoJson{
+		Name:                  h.Name,
+		Description:           h.Description,
+		UUID:                  h.UUID.String(),
+		Version:               h.VersionString(),
+		LastSavedAt:           optionalTime(h.LastSavedAt),
+		LastSavedBy:           h.LastSavedByWhom,
+		LastSavedOn:           h.LastSavedOnHost,
+		LastSavedWith:         h.LastSavedByWhat,
+		PasswordChangedAt:     optionalTime(h.MasterPasswordChangedAt),
+		Iterations:            v.Iterations(),
+		Yubikey:               hasYubikey,
+		Entries:               len(v.List()),
+		EmptyGroups:           []string{},
+		RecentlyUsedEntries:   len(h.RecentlyUsedEntries),
+		TreeDisplayStatus:     []bool{},
+		Filters:               h.Filters,
+		NamedPasswordPolicies: []passwordPolicyJson{},
+		Preferences:           []preferenceJson{},
+	}
+	out.TreeDisplayStatus = append(out.TreeDisplayStatus, h.TreeDisplayStatus...)
+	for _, g := range h.EmptyGroups {
+		out.EmptyGroups = append(out.EmptyGroups, g.String())
+	}
+	for _, p := range h.NamedPasswordPolicies {
+		out.NamedPasswordPolicies = append(out.NamedPasswordPolicies, passwordPolicyJson{
+			Name:         p.Name,
+			Flags:        p.Flags,
+			Length:       p.Length,
+			MinLowercase: p.MinLowercase,
+			MinUppercase: p.MinUppercase,
+			MinDigits:    p.MinDigits,
+			MinSymbols:   p.MinSymbols,
+			Symbols:      p.Symbols,
+		})
+	}
+	for _, p := range h.Preferences {
+		out.Preferences = append(out.Preferences, preferenceJson{Type: string(p.Type), Id: p.Id, Value: p.Value})
+	}
+
+	return writeOutput(opts, out, func() error {
+		return printInfoTable(v, h, hasYubikey)
+	})
+}
+
+type infoJson struct {
+	Name                  string               `json:"name"`
+	Description           string               `json:"description"`
+	UUID                  string               `json:"uuid"`
+	Version               string               `json:"version"`
+	LastSavedAt           *time.Time           `json:"lastSavedAt,omitempty"`
+	LastSavedBy           string               `json:"lastSavedBy"`
+	LastSavedOn           string               `json:"lastSavedOn"`
+	LastSavedWith         string               `json:"lastSavedWith"`
+	PasswordChangedAt     *time.Time           `json:"passwordChangedAt,omitempty"`
+	Iterations            uint                 `json:"iterations"`
+	Yubikey               bool                 `json:"yubikey"`
+	Entries               int                  `json:"entries"`
+	EmptyGroups           []string             `json:"emptyGroups"`
+	RecentlyUsedEntries   int                  `json:"recentlyUsedEntries"`
+	TreeDisplayStatus     []bool               `json:"treeDisplayStatus"`
+	Filters               string               `json:"filters"`
+	NamedPasswordPolicies []passwordPolicyJson `json:"namedPasswordPolicies"`
+	Preferences           []preferenceJson     `json:"preferences"`
+}
+
+type passwordPolicyJson struct {
+	Name         string `json:"name"`
+	Flags        uint16 `json:"flags"`
+	Length       int    `json:"length"`
+	MinLowercase int    `json:"minLowercase"`
+	MinUppercase int    `json:"minUppercase"`
+	MinDigits    int    `json:"minDigits"`
+	MinSymbols   int    `json:"minSymbols"`
+	Symbols      string `json:"symbols"`
+}
+
+type preferenceJson struct {
+	Type  string `json:"type"`
+	Id    int    `json:"id"`
+	Value string `json:"value"`
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func printInfoTable(v passwordsafe.Vault, h passwordsafe.Header, hasYubikey bool) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	printInfo(w, "Name", h.Name)
+	printInfo(w, "Description", h.Description)
+	printInfo(w, "UUID", h.UUID.String())
+	printInfo(w, "Format version", h.VersionString())
+	printInfo(w, "Last saved at", formatInfoTime(h.LastSavedAt))
+	printInfo(w, "Last saved by", h.LastSavedByWhom)
+	printInfo(w, "Last saved on", h.LastSavedOnHost)
+	printInfo(w, "Last saved with", h.LastSavedByWhat)
+	printInfo(w, "Password changed at", formatInfoTime(h.MasterPasswordChangedAt))
+	printInfo(w, "Iterations", fmt.Sprint(v.Iterations()))
+	printInfo(w, "YubiKey", yesNo(hasYubikey))
+	printInfo(w, "Entries", fmt.Sprint(len(v.List())))
+	printInfo(w, "Empty groups", joinGroups(h.EmptyGroups))
+	printInfo(w, "Recently used", fmt.Sprint(len(h.RecentlyUsedEntries)))
+	printInfo(w, "Expanded groups", formatTreeDisplayStatus(h.TreeDisplayStatus))
+	printInfo(w, "Filters", h.Filters)
+	for _, p := range h.NamedPasswordPolicies {
+		printInfo(w, "Password policy", fmt.Sprintf("%s (length %d)", p.Name, p.Length))
+	}
+	for _, p := range h.Preferences {
+		printInfo(w, "Preference", fmt.Sprintf("%c %d = %s", p.Type, p.Id, p.Value))
+	}
+	return w.Flush()
+}
+
+func printInfo(w *tabwriter.Writer, name, value string) {
+	if value != "" {
+		_, _ = fmt.Fprintf(w, "%s:\t%s\n", name, value)
+	}
+}
+
+func formatInfoTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return vault.Timestamp(t).AsString()
+}
+
+func joinGroups(groups []vault.GroupPath) string {
+	var s []string
+	for _, g := range groups {
+		s = append(s, g.String())
+	}
+	return strings.Join(s, ", ")
+}
+
+// formatTreeDisplayStatus returns whether each group is expanded, in display order, e.g. "yes, no".
+func formatTreeDisplayStatus(status []bool) string {
+	var s []string
+	for _, expanded := range status {
+		s = append(s, yesNo(expanded))
+	}
+	return strings.Join(s, ", ")
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
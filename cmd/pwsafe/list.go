@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"notpass-go/pkg/vault"
+	"notpass-go/pkg/vault/format"
+	"notpass-go/pkg/vault/query"
+)
+
+func listEntries(opts options, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	group := vault.ParseGroupPath(fs.Arg(0))
+
+	v, err := openVault(opts)
+	if err != nil {
+		return err
+	}
+	defer closeVault(v)
+
+	var emptyGroups []vault.GroupPath
+	for _, g := range v.EmptyGroups() {
+		if g.HasPrefix(group) {
+			emptyGroups = append(emptyGroups, g)
+		}
+	}
+
+	entries := v.Find(query.InGroup(group, true))
+	root := vault.BuildGroupTree(entries, emptyGroups...)
+	n := root.Find(group)
+	if n == nil {
+		return fmt.Errorf("no group named \"%s\"", group)
+	}
+
+	return writeOutput(opts, newListJson(group, entries, emptyGroups, opts.reveal), func() error {
+		if len(group) > 0 {
+			fmt.Printf("%s/\n", group)
+			printGroupTree(n, 1)
+		} else {
+			printGroupTree(n, 0)
+		}
+		return nil
+	})
+}
+
+type listJson struct {
+	Group       string           `json:"group"`
+	Entries     []map[string]any `json:"entries"`
+	EmptyGroups []string         `json:"emptyGroups"`
+}
+
+func newListJson(group vault.GroupPath, entries []vault.Entry, emptyGroups []vault.GroupPath, reveal bool) listJson {
+	out := listJson{
+		Group:       group.String(),
+		Entries:     []map[string]any{},
+		EmptyGroups: []string{},
+	}
+	sortEntries(entries)
+	for _, e := range entries {
+		out.Entries = append(out.Entries, format.Fields(e, reveal))
+	}
+	for _, g := range emptyGroups {
+		out.EmptyGroups = append(out.EmptyGroups, g.String())
+	}
+	return out
+}
+
+func printGroupTree(n *vault.GroupNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, g := range n.Groups {
+		fmt.Printf("%s%s/\n", indent, vault.GroupPath{g.Name})
+		printGroupTree(g, depth+1)
+	}
+	for _, e := range n.Entries {
+		if e.Username() != "" {
+			fmt.Printf("%s%s\t%s\n", indent, e.Name(), e.Username())
+		} else {
+			fmt.Printf("%s%s\n", indent, e.Name())
+		}
+	}
+}
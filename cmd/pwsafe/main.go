@@ -1,15 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"sort"
 
 	"notpass-go/internal/backend/passwordsafe"
 	"notpass-go/internal/cli"
 	"notpass-go/internal/io"
+	"notpass-go/internal/yubikey"
+	"notpass-go/pkg/sensitive"
 	"notpass-go/pkg/vault"
 	"notpass-go/pkg/vault/query"
 )
@@ -17,45 +22,255 @@ import (
 func main() {
 	vaultFile := flag.String("vault", "", "read vault from this file (required)")
 	yubikey := flag.Bool("yubikey", false, "use YubiKey to open safe")
+	yubikeySlot := flag.Int("yubikey-slot", 2, "YubiKey challenge-response slot (1 or 2)")
+	yubikeySerial := flag.String("yubikey-serial", "", "use the YubiKey with this serial number")
+	yubikeyEmulator := flag.String("yubikey-emulator", "", "use the YubiKey secret backed up to this file instead of a YubiKey")
+	minIterations := flag.Uint("min-iterations", 0, "warn when the safe uses fewer key stretching iterations than this")
+	output := flag.String("output", outputTable, "write results as table (text), json or yaml")
+	reveal := flag.Bool("reveal", false, "show passwords and other secrets instead of redacting them")
+	flag.Usage = usage
 	flag.Parse()
 
-	if *vaultFile == "" {
+	if !validOutput(*output) {
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "invalid value %q for flag -output: expected table, json or yaml\n", *output)
 		flag.Usage()
-		os.Exit(1)
+		os.Exit(2)
 	}
 
-	account := ""
-	username := ""
-	if flag.NArg() > 0 {
-		account = flag.Arg(0)
+	opts := options{
+		vaultFile: *vaultFile,
+		yubikey:   *yubikey || *yubikeySerial != "" || *yubikeyEmulator != "",
+		yubikeyOptions: passwordsafe.YubikeyOptions{
+			Slot:   *yubikeySlot,
+			Serial: *yubikeySerial,
+		},
+		yubikeyEmulator: *yubikeyEmulator,
+		minIterations:   *minIterations,
+		output:          *output,
+		reveal:          *reveal,
+	}
+
+	var err error
+	if c, found := findCommand(flag.Arg(0)); found {
+		err = c.run(opts, flag.Args()[1:])
+	} else {
+		err = showPassword(opts, flag.Args())
+	}
+	if errors.Is(err, context.Canceled) {
+		_, _ = fmt.Fprintln(os.Stderr, "Aborted")
+		os.Exit(exitFailure)
+	} else if err != nil {
+		log.Print(err)
+		os.Exit(exitCode(err))
 	}
-	if flag.NArg() > 1 {
-		username = flag.Arg(1)
+}
+
+// Exit codes let scripts tell why pwsafe failed. Exit code 2 is used by the flag package for
+// invalid options.
+const (
+	exitFailure           = 1
+	exitIncorrectPassword = 3
+	exitIntegrity         = 4
+	exitTruncated         = 5
+	exitUnsupportedFormat = 6
+	exitYubikeyNotFound   = 7
+)
+
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{vault.ErrIncorrectPassword, exitIncorrectPassword},
+	{vault.ErrIntegrity, exitIntegrity},
+	{vault.ErrTruncated, exitTruncated},
+	{vault.ErrUnsupportedFormat, exitUnsupportedFormat},
+	{yubikey.ErrYubikeyNotFound, exitYubikeyNotFound},
+}
+
+func exitCode(err error) int {
+	for _, c := range exitCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return exitFailure
+}
+
+type options struct {
+	vaultFile       string
+	yubikey         bool
+	yubikeyOptions  passwordsafe.YubikeyOptions
+	yubikeyEmulator string
+	minIterations   uint
+	output          string
+	reveal          bool
+}
+
+type command struct {
+	name        string
+	args        string
+	description string
+	run         func(opts options, args []string) error
+}
+
+var commands = []command{
+	{"list", "[GROUP]", "display entries as a tree, optionally only those under GROUP", listEntries},
+	{"audit", "[-expiring-within DAYS] [-max-age DAYS] [-reused] [-min-entropy BITS] [-breaches FILE]",
+		"report expired, old, reused, weak, and breached passwords", auditEntries},
+	{"otp", "ACCOUNT [USERNAME]", "print the current one-time password for an entry", showOtp},
+	{"show", "ACCOUNT [USERNAME]", "display an entry's fields, with secrets hidden unless -reveal is given, and its aliases and shortcuts", showEntry},
+	{"autotype", "ACCOUNT [USERNAME]", "print the keystrokes of an entry's autotype script, with typed text hidden unless -reveal is given", showAutotype},
+	{"run", "ACCOUNT [USERNAME]", "run an entry's run command, e.g. to open an SSH session", runEntry},
+	{"info", "", "display the safe's properties, such as when and where it was last saved", showInfo},
+	{"recover", "[-o FILE]", "salvage entries from a damaged safe and write them, including passwords, as JSON (or YAML with -output yaml)", recoverEntries},
+	{"calibrate", "[-time DURATION]", "print the number of key stretching iterations that unlock a safe in DURATION on this machine", calibrate},
+	{"yubikey", "list | program [-slot N] [-serial SERIAL] [-touch] [-from-vault] [-access-code HEX] [-new-access-code HEX] | backup [-slot N] [-from-vault] FILE | oath [codes [NAME] | add [-touch] | delete NAME]",
+		"list connected YubiKeys, program an HMAC-SHA1 challenge-response slot, back up a secret for -yubikey-emulator, or manage TOTP codes stored on a YubiKey", yubikeyCommand},
+}
+
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	_, _ = fmt.Fprintf(out, "Usage: %s -vault FILE [options] [ACCOUNT [USERNAME]]\n", os.Args[0])
+	_, _ = fmt.Fprintf(out, "       %s -vault FILE [options] COMMAND [ARGS]\n\n", os.Args[0])
+	_, _ = fmt.Fprintf(out, "Without a command, print the password for the entry matching ACCOUNT and USERNAME.\n\n")
+	_, _ = fmt.Fprintf(out, "Commands:\n")
+	for _, c := range commands {
+		_, _ = fmt.Fprintf(out, "  %s %s\n    \t%s\n", c.name, c.args, c.description)
+	}
+	_, _ = fmt.Fprintf(out, "\nOptions:\n")
+	flag.PrintDefaults()
+	_, _ = fmt.Fprintf(out, "\nWith -output json or yaml, every command writes one JSON or YAML document to standard output.\n")
+	_, _ = fmt.Fprintf(out, "Secrets are shown as %q unless -reveal is given. The exceptions are the password\n", sensitive.Redacted)
+	_, _ = fmt.Fprintf(out, "printed without a command, which is always shown, and recover, which always includes\n")
+	_, _ = fmt.Fprintf(out, "secrets. Prompts always go to standard error, as do other messages with -output json or\n")
+	_, _ = fmt.Fprintf(out, "yaml.\n")
+	_, _ = fmt.Fprintf(out, "\nExit status:\n")
+	_, _ = fmt.Fprintf(out, "  %d\tsuccess\n", 0)
+	_, _ = fmt.Fprintf(out, "  %d\tother error\n", exitFailure)
+	_, _ = fmt.Fprintf(out, "  %d\tinvalid options\n", 2)
+	for _, c := range exitCodes {
+		_, _ = fmt.Fprintf(out, "  %d\t%v\n", c.code, c.err)
+	}
+}
+
+func openVault(opts options) (passwordsafe.Vault, error) {
+	password, err := readVaultPassword(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := passwordsafe.OpenVault(opts.vaultFile, password)
+	if err != nil {
+		return nil, err
+	}
+	if err := passwordsafe.CheckIterations(v.Iterations(), opts.minIterations); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return v, nil
+}
+
+// readVaultPassword prompts for the vault's password, and combines it with the YubiKey's response
+// if a YubiKey was requested.
+func readVaultPassword(opts options) (string, error) {
+	if opts.vaultFile == "" {
+		flag.Usage()
+		os.Exit(1)
 	}
 
 	p, err := io.ReadPassword("Password: ")
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 	password := string(p)
 
-	if *yubikey {
-		password, err = passwordsafe.PasswordFromYubikey(string(p))
+	if opts.yubikeyEmulator != "" {
+		passphrase, err := io.ReadPassword("Backup passphrase: ")
 		if err != nil {
-			log.Fatal(err)
+			return "", err
+		}
+		opts.yubikeyOptions.Key, err = yubikey.OpenEmulatorFile(opts.yubikeyEmulator, passphrase)
+		if err != nil {
+			return "", err
 		}
 	}
 
-	v, err := passwordsafe.OpenVault(*vaultFile, password)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer func() {
-		err := v.Close()
+	if opts.yubikey {
+		ctx, stop := yubikeyContext()
+		password, err = passwordsafe.PasswordFromYubikey(ctx, string(p), opts.yubikeyOptions)
+		stop()
 		if err != nil {
-			log.Printf("error: closing database: %v", err)
+			return "", err
 		}
-	}()
+	}
+
+	return password, nil
+}
+
+// yubikeyContext returns a context for YubiKey operations that prompts the user when the YubiKey
+// is waiting to be touched, and is cancelled by Ctrl-C.
+func yubikeyContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx = yubikey.WithTouchCallback(ctx, func() {
+		_, _ = fmt.Fprintln(os.Stderr, "Touch your YubiKey...")
+	})
+	return ctx, stop
+}
+
+func closeVault(v passwordsafe.Vault) {
+	err := v.Close()
+	if err != nil {
+		log.Printf("error: closing database: %v", err)
+	}
+}
+
+func showPassword(opts options, args []string) error {
+	v, err := openVault(opts)
+	if err != nil {
+		return err
+	}
+	defer closeVault(v)
+
+	e, found, err := selectEntry(opts, v, args)
+	if err != nil || !found {
+		return err
+	}
+
+	// The password is the result of this command, so it's never redacted.
+	out := passwordJson{entryRef(e), e.Password().AsString()}
+	return writeOutput(opts, out, func() error {
+		fmt.Println(out.Password)
+		return nil
+	})
+}
+
+type passwordJson struct {
+	entryRefJson
+	Password string `json:"password"`
+}
+
+// selectEntry finds the entry matching the account and username in args, prompting the user to
+// choose if there is more than one. It returns the fully-decrypted entry.
+//
+// With -output json or yaml, there is no prompt, and it is an error if there isn't exactly one
+// match.
+func selectEntry(opts options, v passwordsafe.Vault, args []string) (vault.Entry, bool, error) {
+	account := ""
+	username := ""
+	if len(args) > 0 {
+		account = args[0]
+	}
+	if len(args) > 1 {
+		username = args[1]
+	}
 
 	l := v.Find(query.And(
 		query.Or(
@@ -64,28 +279,35 @@ func main() {
 		),
 		query.Where(vault.UsernameField).Contains(username),
 	))
-	if len(l) == 0 {
+	if len(l) == 0 && opts.structured() {
+		return vault.Entry{}, false, fmt.Errorf("no entries matched \"%s\"", account)
+	} else if len(l) == 0 {
 		fmt.Printf("No entries matched \"%s\"\n", account)
+		return vault.Entry{}, false, nil
 	} else if len(l) == 1 {
-		e, _ := v.Get(l[0].Id())
-		fmt.Println(e.Password())
-	} else {
-		sort.Slice(l, func(i, j int) bool {
-			if l[i].Group() == l[j].Group() {
-				return l[i].Name() < l[j].Name()
-			}
-			return l[i].Group() < l[j].Group()
-		})
-		i, aborted, err := cli.NumberedMenu(l,
-			func(i int, e vault.Entry) string {
-				return fmt.Sprintf("%d %s/%s\t%s", i, e.Group(), e.Name(), e.Username())
-			}, "exit", "exit")
-		if err != nil {
-			log.Fatalln(err)
-		}
-		if !aborted {
-			e, _ := v.Get(l[i].Id())
-			fmt.Println(e.Password().AsString())
-		}
+		e, found := v.Get(l[0].Id())
+		return e, found, nil
+	} else if opts.structured() {
+		return vault.Entry{}, false, fmt.Errorf("%d entries matched \"%s\": add a USERNAME or a more specific ACCOUNT", len(l), account)
 	}
+
+	sortEntries(l)
+	i, aborted, err := cli.NumberedMenu(l,
+		func(i int, e vault.Entry) string {
+			return fmt.Sprintf("%d %s/%s\t%s", i, e.Group(), e.Name(), e.Username())
+		}, "exit", "exit")
+	if err != nil || aborted {
+		return vault.Entry{}, false, err
+	}
+	e, found := v.Get(l[i].Id())
+	return e, found, nil
+}
+
+func sortEntries(l []vault.Entry) {
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].Group() == l[j].Group() {
+			return l[i].Name() < l[j].Name()
+		}
+		return l[i].Group() < l[j].Group()
+	})
 }
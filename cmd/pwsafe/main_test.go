@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/internal/backend/passwordsafe"
+	"notpass-go/internal/yubikey"
+)
+
+// setStdin replaces standard input with a file holding input until the test ends.
+func setStdin(t *testing.T, input string) {
+	path := filepath.Join(t.TempDir(), "stdin")
+	assert.Nil(t, os.WriteFile(path, []byte(input), 0600))
+	f, err := os.Open(path)
+	assert.Nil(t, err)
+
+	oldStdin := os.Stdin
+	os.Stdin = f
+	t.Cleanup(func() {
+		os.Stdin = oldStdin
+		_ = f.Close()
+	})
+}
+
+func TestReadVaultPassword_emulator(t *testing.T) {
+	secret := bytes.Repeat([]byte{0x42}, 20)
+	emulator := filepath.Join(t.TempDir(), "yubikey")
+	assert.Nil(t, yubikey.SaveEmulator(emulator, []byte("backup passphrase"), map[int][]byte{2: secret}))
+	yk, err := yubikey.NewEmulator(map[int][]byte{2: secret})
+	assert.Nil(t, err)
+	expected, err := passwordsafe.PasswordFromYubikey(context.Background(), "hunter2", passwordsafe.YubikeyOptions{Key: yk})
+	assert.Nil(t, err)
+
+	for _, input := range []string{"hunter2\nbackup passphrase\n", "hunter2\r\nbackup passphrase\r\n", "hunter2\nbackup passphrase"} {
+		setStdin(t, input)
+
+		password, err := readVaultPassword(options{vaultFile: testVault, yubikey: true, yubikeyEmulator: emulator})
+
+		assert.Nil(t, err, input)
+		assert.Equal(t, expected, password, input)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"notpass-go/internal/io"
+	"notpass-go/internal/yubikey"
+	"notpass-go/pkg/otp"
+)
+
+// oathCommand manages the TOTP and HOTP credentials stored in a YubiKey's OATH applet.
+func oathCommand(opts options, args []string) error {
+	card, err := yubikey.OpenSmartCard(opts.yubikeyOptions.Serial)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = card.Close() }()
+
+	s, err := yubikey.NewOathSession(card)
+	if err != nil {
+		return err
+	}
+	if s.PasswordRequired() {
+		p, err := io.ReadPassword("OATH password: ")
+		if err != nil {
+			return err
+		}
+		err = s.Validate(string(p))
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(args) == 0 {
+		return showOathCodes(opts, s, "")
+	}
+	switch args[0] {
+	case "codes":
+		filter := ""
+		if len(args) > 1 {
+			filter = args[1]
+		}
+		return showOathCodes(opts, s, filter)
+	case "add":
+		return addOathCredential(opts, s, args[1:])
+	case "delete":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: yubikey oath delete NAME")
+		}
+		err := s.Delete(args[1])
+		if err != nil {
+			return err
+		}
+		printMessage(opts, "Deleted %s\n", args[1])
+		return nil
+	}
+	return fmt.Errorf("usage: yubikey oath [codes [NAME] | add [-touch] | delete NAME]")
+}
+
+// showOathCodes prints the current codes for the credentials whose names contain filter. Codes
+// for HOTP and touch-required credentials are only calculated if they are the only match, since
+// calculating them increments a counter or needs a touch.
+func showOathCodes(opts options, s *yubikey.OathSession, filter string) error {
+	now := time.Now()
+	all, err := s.CalculateAll(now)
+	if err != nil {
+		return err
+	}
+
+	var codes []yubikey.OathCode
+	for _, c := range all {
+		if strings.Contains(strings.ToLower(c.Credential.Name), strings.ToLower(filter)) {
+			codes = append(codes, c)
+		}
+	}
+	if len(codes) == 0 && !opts.structured() {
+		fmt.Printf("No credentials matched \"%s\"\n", filter)
+		return nil
+	}
+
+	if len(codes) == 1 && codes[0].Code == "" {
+		if codes[0].TouchRequired {
+			_, _ = fmt.Fprintln(os.Stderr, "Touch your YubiKey...")
+		}
+		codes[0], err = s.Calculate(codes[0].Credential, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	out := []oathCodeJson{}
+	for _, c := range codes {
+		code := oathCodeJson{Name: c.Credential.Name, Type: c.Credential.Type, Code: c.Code, TouchRequired: c.TouchRequired}
+		if c.Code != "" && c.Credential.Type != otp.HOTP {
+			code.ExpiresAt = &c.Expires
+		}
+		out = append(out, code)
+	}
+
+	return writeOutput(opts, out, func() error {
+		return printOathCodes(codes, now)
+	})
+}
+
+// oathCodeJson is a credential's current code. Code is empty for HOTP and touch-required
+// credentials unless they were the only match.
+type oathCodeJson struct {
+	Name          string     `json:"name"`
+	Type          otp.Type   `json:"type"`
+	Code          string     `json:"code,omitempty"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+	TouchRequired bool       `json:"touchRequired"`
+}
+
+func printOathCodes(codes []yubikey.OathCode, now time.Time) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, c := range codes {
+		switch {
+		case c.Code != "" && c.Credential.Type == otp.HOTP:
+			_, _ = fmt.Fprintf(w, "%s\t%s\t\n", c.Credential.Name, c.Code)
+		case c.Code != "":
+			remaining := int(c.Expires.Sub(now).Round(time.Second) / time.Second)
+			_, _ = fmt.Fprintf(w, "%s\t%s\t(%ds remaining)\n", c.Credential.Name, c.Code, remaining)
+		case c.TouchRequired:
+			_, _ = fmt.Fprintf(w, "%s\t\t(touch required)\n", c.Credential.Name)
+		default:
+			_, _ = fmt.Fprintf(w, "%s\t\t(HOTP)\n", c.Credential.Name)
+		}
+	}
+	return w.Flush()
+}
+
+func addOathCredential(opts options, s *yubikey.OathSession, args []string) error {
+	fs := flag.NewFlagSet("yubikey oath add", flag.ContinueOnError)
+	touch := fs.Bool("touch", false, "require the button to be touched to calculate codes")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	uri, err := io.ReadPassword("otpauth URI: ")
+	if err != nil {
+		return err
+	}
+	k, err := otp.ParseURI(strings.TrimSpace(string(uri)))
+	if err != nil {
+		return err
+	}
+
+	c, err := s.Put(k, *touch)
+	if err != nil {
+		return err
+	}
+	printMessage(opts, "Added %s\n", c.Name)
+
+	return nil
+}
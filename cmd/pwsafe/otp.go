@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"notpass-go/pkg/otp"
+	"notpass-go/pkg/vault"
+)
+
+func showOtp(opts options, args []string) error {
+	fs := flag.NewFlagSet("otp", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	v, err := openVault(opts)
+	if err != nil {
+		return err
+	}
+	defer closeVault(v)
+
+	e, found, err := selectEntry(opts, v, fs.Args())
+	if err != nil || !found {
+		return err
+	}
+
+	t := now()
+	out, err := newOtpJson(e, t)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(opts, out, func() error {
+		if out.Counter != nil {
+			fmt.Printf("%s\t(counter %d)\n", out.Code, *out.Counter)
+		} else {
+			fmt.Printf("%s\t(%ds remaining)\n", out.Code, int(out.ExpiresAt.Sub(t).Round(time.Second)/time.Second))
+		}
+		return nil
+	})
+}
+
+// newOtpJson calculates the entry's one-time password at time t.
+func newOtpJson(e vault.Entry, t time.Time) (otpJson, error) {
+	k, err := otp.KeyFromEntry(e)
+	if err != nil {
+		return otpJson{}, err
+	}
+
+	code, expires, err := k.Code(t)
+	if err != nil {
+		return otpJson{}, err
+	}
+
+	out := otpJson{Code: code, Type: k.Type}
+	if k.Type == otp.HOTP {
+		out.Counter = &k.Counter
+	} else {
+		out.ExpiresAt = &expires
+	}
+	return out, nil
+}
+
+// otpJson is a one-time password. TOTP codes have the time they expire, and HOTP codes the
+// counter they were calculated for.
+type otpJson struct {
+	Code      string     `json:"code"`
+	Type      otp.Type   `json:"type"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Counter   *uint64    `json:"counter,omitempty"`
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"notpass-go/pkg/vault"
+)
+
+// Values for the -output option. JSON and YAML output share a schema, which is defined by the
+// json struct tags of each command's output type.
+const (
+	outputTable = "table"
+	outputJson  = "json"
+	outputYaml  = "yaml"
+)
+
+// now is the current time, which tests replace so that their output doesn't change.
+var now = time.Now
+
+func validOutput(output string) bool {
+	return output == outputTable || output == outputJson || output == outputYaml
+}
+
+// structured reports whether the command's results are written as JSON or YAML.
+func (o options) structured() bool {
+	return o.output != outputTable
+}
+
+// writeOutput writes v to standard output as JSON or YAML, or calls table to print it as text.
+func writeOutput(opts options, v any, table func() error) error {
+	if !opts.structured() {
+		return table()
+	}
+	return encodeOutput(os.Stdout, opts.output, v)
+}
+
+func encodeOutput(w io.Writer, output string, v any) error {
+	if output == outputYaml {
+		return encodeYaml(w, v)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+// encodeYaml writes v as YAML with the same keys, in the same order, as its JSON encoding. JSON is
+// valid YAML, so it is parsed back into a YAML document and re-encoded in block style.
+func encodeYaml(w io.Writer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	err = yaml.NewDecoder(bytes.NewReader(b)).Decode(&doc)
+	if err != nil {
+		return err
+	}
+	clearYamlStyle(&doc)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	err = enc.Encode(&doc)
+	if err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// clearYamlStyle removes the flow style and quotes the JSON parsed with, leaving the encoder to
+// quote only strings that would otherwise be read as another type.
+func clearYamlStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		clearYamlStyle(c)
+	}
+}
+
+// printMessage prints a message that reports what a command did, rather than a result. With
+// -output json or yaml, it goes to standard error so that standard output is only the results.
+func printMessage(opts options, format string, a ...any) {
+	w := os.Stdout
+	if opts.structured() {
+		w = os.Stderr
+	}
+	_, _ = fmt.Fprintf(w, format, a...)
+}
+
+// entryRefJson identifies an entry in the output of commands that refer to other entries.
+type entryRefJson struct {
+	Id       string `json:"id"`
+	Group    string `json:"group,omitempty"`
+	Name     string `json:"name"`
+	Username string `json:"username,omitempty"`
+}
+
+func entryRef(e vault.Entry) entryRefJson {
+	return entryRefJson{Id: e.Id(), Group: e.Group(), Name: e.Name(), Username: e.Username()}
+}
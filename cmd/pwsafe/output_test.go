@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/pkg/sensitive"
+	"notpass-go/pkg/vault"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+const testVault = "../../internal/backend/passwordsafe/v3/testdata/test.psafe3"
+
+func TestMain(m *testing.M) {
+	// The safe's timestamps are converted to local time, and the audit depends on the current
+	// time, so both are fixed for the golden files.
+	time.Local = time.UTC
+	now = func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) }
+	os.Exit(m.Run())
+}
+
+// The JSON and YAML output of each command is a stable schema that scripts rely on, so it's
+// compared with golden files. Run "go test ./cmd/pwsafe -update" to rewrite them after an
+// intended change.
+func TestOutput_golden(t *testing.T) {
+	testCases := []struct {
+		name string
+		run  func(options, []string) error
+		args []string
+	}{
+		{"list", listEntries, []string{"Finance"}},
+		{"show", showEntry, []string{"Imperial"}},
+		{"audit", auditEntries, []string{"-reused", "-max-age", "365"}},
+		{"info", showInfo, nil},
+	}
+
+	for _, tc := range testCases {
+		for _, output := range []string{outputJson, outputYaml} {
+			name := tc.name + "." + output
+			t.Run(name, func(t *testing.T) {
+				opts := options{vaultFile: testVault, output: output}
+				actual := runCommand(t, opts, tc.run, tc.args)
+				assertGolden(t, name, actual)
+			})
+		}
+	}
+}
+
+func TestOutput_golden_otp(t *testing.T) {
+	testCases := []struct {
+		name  string
+		entry vault.Entry
+	}{
+		{"otp-totp", vault.NewEntry().
+			With(vault.TwoFactorKeyField, sensitive.String("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")).
+			With(vault.TotpDigitsField, vault.Int(8))},
+		{"otp-hotp", vault.NewEntry().
+			WithNote("otpauth://hotp/alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&counter=3")},
+	}
+
+	for _, tc := range testCases {
+		out, err := newOtpJson(tc.entry, now())
+		assert.Nil(t, err, tc.name)
+
+		for _, output := range []string{outputJson, outputYaml} {
+			var b bytes.Buffer
+			assert.Nil(t, encodeOutput(&b, output, out))
+			assertGolden(t, tc.name+"."+output, b.String())
+		}
+	}
+}
+
+// runCommand runs a command with the test safe's password on standard input, and returns what it
+// wrote to standard output.
+func runCommand(t *testing.T, opts options, run func(options, []string) error, args []string) string {
+	setStdin(t, "hunter2\n")
+	stdout, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	assert.Nil(t, err)
+
+	oldStdout := os.Stdout
+	os.Stdout = stdout
+	err = run(opts, args)
+	os.Stdout = oldStdout
+	assert.Nil(t, err)
+
+	_ = stdout.Close()
+	b, err := os.ReadFile(stdout.Name())
+	assert.Nil(t, err)
+	return string(b)
+}
+
+func assertGolden(t *testing.T, name, actual string) {
+	path := filepath.Join("testdata", name)
+	if *update {
+		assert.Nil(t, os.MkdirAll("testdata", 0755))
+		assert.Nil(t, os.WriteFile(path, []byte(actual), 0644))
+		return
+	}
+	expected, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, string(expected), actual, name)
+}
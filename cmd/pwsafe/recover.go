@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"notpass-go/internal/backend/passwordsafe"
+	"notpass-go/internal/backend/passwordsafe/v3"
+	"notpass-go/pkg/vault/format"
+)
+
+type recoveryJson struct {
+	Name          string           `json:"name"`
+	Description   string           `json:"description"`
+	Authenticated bool             `json:"authenticated"`
+	EmptyGroups   []string         `json:"emptyGroups"`
+	Entries       []map[string]any `json:"entries"`
+	Lost          []v3.ByteRange   `json:"lost"`
+}
+
+func recoverEntries(opts options, args []string) error {
+	fs := flag.NewFlagSet("recover", flag.ExitOnError)
+	output := fs.String("o", "", "write the recovered entries to this new file instead of standard output")
+	_ = fs.Parse(args)
+
+	password, err := readVaultPassword(opts)
+	if err != nil {
+		return err
+	}
+
+	r, err := passwordsafe.RecoverVault(opts.vaultFile, password)
+	if err != nil {
+		return err
+	}
+
+	out := recoveryJson{
+		Name:          r.Header.Name,
+		Description:   r.Header.Description,
+		Authenticated: r.Authenticated,
+		EmptyGroups:   []string{},
+		Entries:       []map[string]any{},
+		Lost:          r.Lost,
+	}
+	if out.Lost == nil {
+		out.Lost = []v3.ByteRange{}
+	}
+	for _, g := range r.Header.EmptyGroups {
+		out.EmptyGroups = append(out.EmptyGroups, g.String())
+	}
+	for _, e := range r.Entries {
+		out.Entries = append(out.Entries, format.Fields(e, true))
+	}
+
+	// The recovered entries are always written as JSON, or YAML if requested, since the point is
+	// to save them somewhere else.
+	format := outputJson
+	if opts.output == outputYaml {
+		format = outputYaml
+	}
+	if *output == "" {
+		err = encodeOutput(os.Stdout, format, out)
+	} else {
+		err = writeRecoveryFile(*output, format, out)
+	}
+	if err != nil {
+		return err
+	}
+
+	printRecoverySummary(r)
+	return nil
+}
+
+// writeRecoveryFile writes recovered entries to a new file that only the user can read, since it
+// contains their passwords in plain text.
+func writeRecoveryFile(path, format string, r recoveryJson) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	err = encodeOutput(f, format, r)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func printRecoverySummary(r *v3.Recovery) {
+	_, _ = fmt.Fprintf(os.Stderr, "Recovered %d entries\n", len(r.Entries))
+	if r.Authenticated {
+		_, _ = fmt.Fprintln(os.Stderr, "The safe passed its integrity check, so nothing was lost")
+		return
+	}
+	for _, l := range r.Lost {
+		_, _ = fmt.Fprintf(os.Stderr, "Lost bytes %d-%d (%d bytes)\n", l.Start, l.End-1, l.End-l.Start)
+	}
+	_, _ = fmt.Fprintln(os.Stderr, "Warning: the safe failed its integrity check, so recovered entries may be damaged")
+}
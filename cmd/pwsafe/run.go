@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"notpass-go/pkg/vault/runcmd"
+)
+
+func runEntry(opts options, args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	v, err := openVault(opts)
+	if err != nil {
+		return err
+	}
+	defer closeVault(v)
+
+	e, found, err := selectEntry(opts, v, fs.Args())
+	if err != nil || !found {
+		return err
+	}
+	if e.RunCommand() == "" {
+		return fmt.Errorf("entry has no run command")
+	}
+
+	argv, err := runcmd.Expand(e)
+	if err != nil {
+		return err
+	}
+
+	// The arguments are passed directly to the program rather than through a shell, so values
+	// such as passwords can't inject commands.
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"notpass-go/internal/backend/passwordsafe"
+	"notpass-go/pkg/vault"
+	"notpass-go/pkg/vault/format"
+	"notpass-go/pkg/vault/query"
+)
+
+type showJson struct {
+	Entry     map[string]any `json:"entry"`
+	Aliases   []entryRefJson `json:"aliases"`
+	Shortcuts []entryRefJson `json:"shortcuts"`
+}
+
+func showEntry(opts options, args []string) error {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	v, err := openVault(opts)
+	if err != nil {
+		return err
+	}
+	defer closeVault(v)
+
+	e, found, err := selectEntry(opts, v, fs.Args())
+	if err != nil || !found {
+		return err
+	}
+
+	aliases := v.Find(query.Where(vault.AliasOfField).Equals(e.Id()))
+	shortcuts := v.Find(query.Where(vault.ShortcutOfField).Equals(e.Id()))
+	sortEntries(aliases)
+	sortEntries(shortcuts)
+
+	out := showJson{
+		Entry:     format.Fields(e, opts.reveal),
+		Aliases:   []entryRefJson{},
+		Shortcuts: []entryRefJson{},
+	}
+	for _, a := range aliases {
+		out.Aliases = append(out.Aliases, entryRef(a))
+	}
+	for _, s := range shortcuts {
+		out.Shortcuts = append(out.Shortcuts, entryRef(s))
+	}
+
+	return writeOutput(opts, out, func() error {
+		return printEntry(v, e, aliases, shortcuts, opts.reveal)
+	})
+}
+
+func printEntry(v passwordsafe.Vault, e vault.Entry, aliases, shortcuts []vault.Entry, reveal bool) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fields := e.Fields()
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if k == vault.AliasOfField || k == vault.ShortcutOfField {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "%s:\t%s\n", k, format.Value(fields[k], reveal))
+	}
+
+	if id := e.Get(vault.AliasOfField); id != nil {
+		_, _ = fmt.Fprintf(w, "Alias of:\t%s\n", describeEntry(v, id.AsString()))
+	}
+	if id := e.Get(vault.ShortcutOfField); id != nil {
+		_, _ = fmt.Fprintf(w, "Shortcut to:\t%s\n", describeEntry(v, id.AsString()))
+	}
+	for _, a := range aliases {
+		_, _ = fmt.Fprintf(w, "Alias:\t%s\n", entryPath(a))
+	}
+	for _, s := range shortcuts {
+		_, _ = fmt.Fprintf(w, "Shortcut:\t%s\n", entryPath(s))
+	}
+	return w.Flush()
+}
+
+func describeEntry(v passwordsafe.Vault, id string) string {
+	e, found := v.Get(id)
+	if !found {
+		return id
+	}
+	return entryPath(e)
+}
+
+func entryPath(e vault.Entry) string {
+	path := e.Name()
+	if e.Group() != "" {
+		path = e.Group() + "/" + path
+	}
+	if e.Username() != "" {
+		path += " (" + e.Username() + ")"
+	}
+	return path
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"notpass-go/internal/cli"
+	"notpass-go/internal/io"
+	"notpass-go/internal/yubikey"
+)
+
+func yubikeyCommand(opts options, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "list":
+			return listYubikeys(opts)
+		case "program":
+			return programYubikey(opts, args[1:])
+		case "backup":
+			return backupYubikey(opts, args[1:])
+		case "oath":
+			return oathCommand(opts, args[1:])
+		}
+	}
+	return fmt.Errorf("usage: yubikey list | program [options] | backup [options] FILE | oath [COMMAND]")
+}
+
+type yubikeyJson struct {
+	Serial  string `json:"serial"`
+	Type    string `json:"type"`
+	Version string `json:"version"`
+}
+
+func listYubikeys(opts options) error {
+	infos, err := yubikey.List()
+	if err != nil {
+		return err
+	}
+
+	out := []yubikeyJson{}
+	for _, info := range infos {
+		out = append(out, yubikeyJson{Serial: info.Serial, Type: info.Type, Version: info.Version})
+	}
+
+	return writeOutput(opts, out, func() error {
+		if len(out) == 0 {
+			fmt.Println("No YubiKeys found")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "SERIAL\tTYPE\tVERSION")
+		for _, k := range out {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", k.Serial, k.Type, k.Version)
+		}
+		return w.Flush()
+	})
+}
+
+// programYubikey writes an HMAC-SHA1 secret to a YubiKey slot. With -from-vault, the secret is
+// read from the header of the YubiKey-protected safe, which makes it possible to provision a
+// backup key for a safe.
+func programYubikey(opts options, args []string) error {
+	fs := flag.NewFlagSet("yubikey program", flag.ContinueOnError)
+	slot := fs.Int("slot", 2, "program this slot (1 or 2)")
+	serial := fs.String("serial", "", "program the YubiKey with this serial number")
+	touch := fs.Bool("touch", false, "require the button to be touched for each challenge")
+	fromVault := fs.Bool("from-vault", false, "use the secret stored in the vault instead of prompting for one")
+	accessCode := fs.String("access-code", "", "current access code of the slot, in hex")
+	newAccessCode := fs.String("new-access-code", "", "protect the slot with this access code, in hex")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	cfg := yubikey.HmacSha1Config{RequireTouch: *touch}
+	cfg.AccessCode, err = hex.DecodeString(*accessCode)
+	if err != nil {
+		return fmt.Errorf("invalid access code: %w", err)
+	}
+	cfg.NewAccessCode, err = hex.DecodeString(*newAccessCode)
+	if err != nil {
+		return fmt.Errorf("invalid new access code: %w", err)
+	}
+
+	cfg.Secret, err = readSecret(opts, *fromVault)
+	if err != nil {
+		return err
+	}
+
+	var yk yubikey.YubiKey
+	if *serial != "" {
+		yk, err = yubikey.OpenBySerialNumber(*serial)
+	} else {
+		yk, err = yubikey.Open()
+	}
+	if err != nil {
+		return err
+	}
+	defer func() { _ = yk.Close() }()
+
+	s, err := yk.Serial()
+	if err != nil {
+		return err
+	}
+	ok, err := cli.Confirm(fmt.Sprintf("Overwrite slot %d on YubiKey %s?", *slot, s))
+	if err != nil || !ok {
+		return err
+	}
+
+	ctx, stop := yubikeyContext()
+	defer stop()
+	err = yk.ProgramHmacSha1(ctx, *slot, cfg)
+	if err != nil {
+		return err
+	}
+	printMessage(opts, "Programmed slot %d on YubiKey %s\n", *slot, s)
+
+	return nil
+}
+
+// backupYubikey saves an HMAC-SHA1 secret to an encrypted file that can be used with
+// -yubikey-emulator to open a safe without the YubiKey.
+func backupYubikey(opts options, args []string) error {
+	fs := flag.NewFlagSet("yubikey backup", flag.ContinueOnError)
+	slot := fs.Int("slot", 2, "save the secret for this slot (1 or 2)")
+	fromVault := fs.Bool("from-vault", false, "use the secret stored in the vault instead of prompting for one")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: yubikey backup [-slot N] [-from-vault] FILE")
+	}
+
+	secret, err := readSecret(opts, *fromVault)
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := io.ReadPassword("Backup passphrase: ")
+	if err != nil {
+		return err
+	}
+	confirm, err := io.ReadPassword("Confirm passphrase: ")
+	if err != nil {
+		return err
+	}
+	if string(passphrase) != string(confirm) {
+		return fmt.Errorf("passphrases do not match")
+	}
+
+	err = yubikey.SaveEmulator(fs.Arg(0), passphrase, map[int][]byte{*slot: secret})
+	if err != nil {
+		return err
+	}
+	printMessage(opts, "Saved slot %d secret to %s\n", *slot, fs.Arg(0))
+
+	return nil
+}
+
+// readSecret reads an HMAC-SHA1 secret from the vault's header, or prompts for it in hex.
+func readSecret(opts options, fromVault bool) ([]byte, error) {
+	if fromVault {
+		v, err := openVault(opts)
+		if err != nil {
+			return nil, err
+		}
+		defer closeVault(v)
+
+		secret, found := v.YubikeySecret()
+		if !found {
+			return nil, fmt.Errorf("vault does not contain a YubiKey secret")
+		}
+		return secret, nil
+	}
+
+	s, err := io.ReadPassword("Secret (hex): ")
+	if err != nil {
+		return nil, err
+	}
+	secret, err := hex.DecodeString(strings.TrimSpace(string(s)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret: %w", err)
+	}
+	return secret, nil
+}
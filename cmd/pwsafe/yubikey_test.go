@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/internal/yubikey"
+)
+
+func TestBackupYubikey_piped(t *testing.T) {
+	emulator := filepath.Join(t.TempDir(), "yubikey")
+	setStdin(t, "4242424242424242424242424242424242424242\nbackup passphrase\nbackup passphrase\n")
+
+	err := backupYubikey(options{}, []string{emulator})
+
+	assert.Nil(t, err)
+	// The passphrase must be the one typed, without its line ending, so that the backup opens
+	// whether the passphrase is typed at a terminal or piped.
+	yk, err := yubikey.OpenEmulatorFile(emulator, []byte("backup passphrase"))
+	assert.Nil(t, err)
+	expected, err := yubikey.NewEmulator(map[int][]byte{2: bytes.Repeat([]byte{0x42}, 20)})
+	assert.Nil(t, err)
+	challenge := []byte("challenge")
+	r1, err := yk.ChallengeResponseHmacSha1(context.Background(), 2, challenge)
+	assert.Nil(t, err)
+	r2, err := expected.ChallengeResponseHmacSha1(context.Background(), 2, challenge)
+	assert.Nil(t, err)
+	assert.Equal(t, r2, r1)
+}
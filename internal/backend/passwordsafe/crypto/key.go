@@ -3,19 +3,53 @@ package crypto
 import (
 	"bytes"
 	"crypto/sha256"
-	"fmt"
+	"math"
+	"time"
+
+	"notpass-go/pkg/vault"
 )
 
+// MinIterations is the smallest number of key stretching iterations PasswordSafe allows.
+const MinIterations = 2048
+
 func DeriveKeySha256(password, salt []byte, iterations uint, masterKeyHash []byte) ([]byte, error) {
-	k := sha256.Sum256(append(password, salt...))
-	for i := uint(0); i < iterations; i++ {
-		k = sha256.Sum256(k[:])
-	}
+	k := StretchKeySha256(password, salt, iterations)
 
 	kh := sha256.Sum256(k[:])
 	if bytes.Equal(kh[:], masterKeyHash) {
 		return k[:], nil
 	} else {
-		return nil, fmt.Errorf("incorrect password")
+		return nil, vault.ErrIncorrectPassword
 	}
 }
+
+// StretchKeySha256 computes the stretched key P' from the PasswordSafe V3 specification.
+func StretchKeySha256(password, salt []byte, iterations uint) [sha256.Size]byte {
+	k := sha256.Sum256(append(append([]byte{}, password...), salt...))
+	for i := uint(0); i < iterations; i++ {
+		k = sha256.Sum256(k[:])
+	}
+	return k
+}
+
+// CalibrateIterations returns the number of key stretching iterations that take about target
+// on this machine, and never less than MinIterations.
+func CalibrateIterations(target time.Duration) uint {
+	// Double the sample size until it takes long enough to measure reliably.
+	sample := uint(MinIterations)
+	var elapsed time.Duration
+	for {
+		start := time.Now()
+		_ = StretchKeySha256([]byte("calibrate"), make([]byte, 32), sample)
+		elapsed = time.Since(start)
+		if elapsed >= calibrationSampleTime || elapsed >= target || sample >= math.MaxUint32/2 {
+			break
+		}
+		sample *= 2
+	}
+
+	iterations := float64(sample) * float64(target) / float64(max(elapsed, 1))
+	return uint(min(max(iterations, MinIterations), math.MaxUint32))
+}
+
+const calibrationSampleTime = 50 * time.Millisecond
@@ -1,11 +1,14 @@
 package crypto
 
 import (
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"notpass-go/internal/testutil"
+	"notpass-go/pkg/vault"
 )
 
 func TestDeriveKeySha256(t *testing.T) {
@@ -27,6 +30,37 @@ func TestDeriveKeySha256_WrongPassword(t *testing.T) {
 
 	k, err := DeriveKeySha256([]byte("hunter3"), salt, iterations, masterKeyHash)
 
-	assert.NotNil(t, err)
+	assert.ErrorIs(t, err, vault.ErrIncorrectPassword)
 	assert.Nil(t, k)
 }
+
+func TestCalibrateIterations(t *testing.T) {
+	assert.Equal(t, uint(MinIterations), CalibrateIterations(0))
+
+	short := CalibrateIterations(time.Millisecond)
+	long := CalibrateIterations(100 * time.Millisecond)
+	assert.GreaterOrEqual(t, short, uint(MinIterations))
+	assert.Greater(t, long, short)
+
+	start := time.Now()
+	_ = StretchKeySha256([]byte("hunter2"), make([]byte, 32), long)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func BenchmarkStretchKeySha256(b *testing.B) {
+	salt := testutil.UnHex("2da694c7adff6775c75931ca2bee48250cbf5155ac3cd590c558ebc4037b5d59")
+	for _, iterations := range []uint{MinIterations, 262144} {
+		b.Run(strconv.Itoa(int(iterations)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = StretchKeySha256([]byte("hunter2"), salt, iterations)
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N)/float64(iterations), "ns/iteration")
+		})
+	}
+}
+
+func BenchmarkCalibrateIterations(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = CalibrateIterations(100 * time.Millisecond)
+	}
+}
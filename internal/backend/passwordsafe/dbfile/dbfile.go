@@ -3,12 +3,15 @@ package dbfile
 import (
 	"bytes"
 	"crypto/sha1"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 
 	"notpass-go/internal/backend/passwordsafe/crypto"
 	"notpass-go/internal/backend/passwordsafe/v3"
+	"notpass-go/pkg/vault"
 )
 
 func GuessFormat(dbPath, password string) (Format, error) {
@@ -39,7 +42,9 @@ func GuessFormat(dbPath, password string) (Format, error) {
 func isV1V2File(f *os.File, password string) (bool, error) {
 	hdr := make([]byte, v1v2RndSize+sha1.Size)
 	_, err := f.ReadAt(hdr, 0)
-	if err != nil {
+	if errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("%w (expected at least %d bytes)", vault.ErrTruncated, len(hdr))
+	} else if err != nil {
 		return false, fmt.Errorf("f.Read: %w", err)
 	}
 
@@ -53,7 +58,9 @@ func isV1V2File(f *os.File, password string) (bool, error) {
 func isV3File(f *os.File) (bool, error) {
 	magic := make([]byte, len(v3.Magic))
 	_, err := f.ReadAt(magic, 0)
-	if err != nil {
+	if errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("%w (expected at least %d bytes)", vault.ErrTruncated, len(magic))
+	} else if err != nil {
 		return false, fmt.Errorf("f.Read: %w", err)
 	}
 	return bytes.Equal(magic, []byte(v3.Magic)), nil
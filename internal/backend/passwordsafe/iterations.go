@@ -0,0 +1,31 @@
+package passwordsafe
+
+import (
+	"fmt"
+	"time"
+
+	"notpass-go/internal/backend/passwordsafe/crypto"
+)
+
+// DefaultUnlockTime is how long unlocking a safe should take when choosing its number of key
+// stretching iterations.
+const DefaultUnlockTime = time.Second
+
+// CheckIterations returns an error if iterations is below the minimum PasswordSafe allows, or
+// below floor. Safes that use too few iterations are easier to brute force.
+func CheckIterations(iterations, floor uint) error {
+	if iterations < crypto.MinIterations {
+		return fmt.Errorf("safe uses %d key stretching iterations, fewer than the minimum of %d allowed by PasswordSafe",
+			iterations, crypto.MinIterations)
+	}
+	if iterations < floor {
+		return fmt.Errorf("safe uses %d key stretching iterations, fewer than the required %d", iterations, floor)
+	}
+	return nil
+}
+
+// CalibrateIterations returns the number of key stretching iterations that make unlocking a safe
+// take about target on this machine.
+func CalibrateIterations(target time.Duration) uint {
+	return crypto.CalibrateIterations(target)
+}
@@ -0,0 +1,31 @@
+package passwordsafe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckIterations(t *testing.T) {
+	testCases := []struct {
+		iterations uint
+		floor      uint
+		ok         bool
+	}{
+		{2048, 0, true},
+		{2047, 0, false},
+		{0, 0, false},
+		{100000, 100000, true},
+		{99999, 100000, false},
+		{1000, 100, false},
+	}
+
+	for _, tc := range testCases {
+		err := CheckIterations(tc.iterations, tc.floor)
+		if tc.ok {
+			assert.Nil(t, err, "iterations %d, floor %d", tc.iterations, tc.floor)
+		} else {
+			assert.NotNil(t, err, "iterations %d, floor %d", tc.iterations, tc.floor)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package v3
+
+import (
+	"maps"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"notpass-go/pkg/vault"
+)
+
+// resolveReferences resolves aliases and shortcuts, which PasswordSafe stores as entries whose
+// password refers to another (base) entry.
+//
+// An alias's password is "[[REF]]", and it takes only its password from the base entry. A
+// shortcut's password is "[~REF~]", and it takes every field it doesn't have itself from the base
+// entry. REF is the base entry's UUID, or "title", "group:title" or "group:title:username" as
+// typed into PasswordSafe.
+//
+// Resolved entries are marked with the base entry's ID in the "aliasOf" or "shortcutOf" field.
+// References to missing or ambiguous entries, and cycles of references, are left unresolved, so
+// the entry's password is the literal reference.
+func resolveReferences(entries map[string]vault.Entry) {
+	unresolved := maps.Clone(entries)
+	for id, e := range unresolved {
+		base, kind, ok := findBase(unresolved, e, map[string]bool{id: true})
+		if !ok {
+			continue
+		}
+
+		resolved := vault.NewEntryWithFields(e.Fields())
+		if kind == vault.ShortcutOfField {
+			for k, v := range base.Fields() {
+				if e.Get(k) == nil {
+					resolved = resolved.With(k, v)
+				}
+			}
+		}
+		resolved = resolved.With(vault.PasswordField, base.Get(vault.PasswordField))
+		resolved = resolved.With(kind, vault.String(base.Id()))
+		entries[id] = resolved
+	}
+}
+
+// findBase follows e's chain of references to the entry that holds its password. It returns the
+// first entry in the chain that e refers to, which is e's base, and how e refers to it.
+func findBase(entries map[string]vault.Entry, e vault.Entry, seen map[string]bool) (vault.Entry, string, bool) {
+	ref, kind, ok := parseReference(e.Password().AsString())
+	if !ok {
+		return vault.Entry{}, "", false
+	}
+	base, ok := findReferencedEntry(entries, ref)
+	if !ok || seen[base.Id()] {
+		return vault.Entry{}, "", false
+	}
+
+	// The base entry may itself be a reference. PasswordSafe doesn't create these, but a
+	// hand-edited or imported database might have them.
+	if _, _, isRef := parseReference(base.Password().AsString()); isRef {
+		seen[base.Id()] = true
+		next, _, ok := findBase(entries, base, seen)
+		if !ok {
+			return vault.Entry{}, "", false
+		}
+		base = vault.NewEntryWithFields(base.Fields()).With(vault.PasswordField, next.Get(vault.PasswordField))
+	}
+	return base, kind, true
+}
+
+func parseReference(password string) (string, string, bool) {
+	if strings.HasPrefix(password, "[[") && strings.HasSuffix(password, "]]") && len(password) > 4 {
+		return password[2 : len(password)-2], vault.AliasOfField, true
+	}
+	if strings.HasPrefix(password, "[~") && strings.HasSuffix(password, "~]") && len(password) > 4 {
+		return password[2 : len(password)-2], vault.ShortcutOfField, true
+	}
+	return "", "", false
+}
+
+func findReferencedEntry(entries map[string]vault.Entry, ref string) (vault.Entry, bool) {
+	if id, err := uuid.Parse(ref); err == nil {
+		if e, ok := entries[id.String()]; ok {
+			return e, true
+		}
+	}
+
+	var group, title, username string
+	parts := strings.Split(ref, ":")
+	switch len(parts) {
+	case 1:
+		title = parts[0]
+	case 2:
+		group, title = parts[0], parts[1]
+	case 3:
+		group, title, username = parts[0], parts[1], parts[2]
+	default:
+		return vault.Entry{}, false
+	}
+	groupPath := groupPathSyntax.Parse(group)
+
+	var match vault.Entry
+	matches := 0
+	for _, e := range entries {
+		if e.Name() != title || (len(parts) > 1 && !e.GroupPath().Equal(groupPath)) || (len(parts) > 2 && e.Username() != username) {
+			continue
+		}
+		match = e
+		matches++
+	}
+	return match, matches == 1
+}
@@ -0,0 +1,80 @@
+package v3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/pkg/sensitive"
+	"notpass-go/pkg/vault"
+)
+
+func Test_resolveReferences(t *testing.T) {
+	const baseId = "bcdc6634-9e1a-4657-8cbf-36a4bc1a09cd"
+	entry := func(id, group, name, username, password string) vault.Entry {
+		return vault.NewEntry().WithId(id).WithGroup(group).WithName(name).WithUsername(username).
+			WithPassword(sensitive.String(password))
+	}
+
+	entries := map[string]vault.Entry{}
+	for _, e := range []vault.Entry{
+		entry(baseId, "Finance", "Bank", "luke", "hunter2").WithUrl("https://bank.example.com/"),
+		entry("2", "Finance", "Bank", "leia", "alderaan"),
+		entry("alias-uuid", "Shared", "Bank alias", "", "[[bcdc66349e1a46578cbf36a4bc1a09cd]]"),
+		entry("alias-title", "", "Alias by title", "", "[[Finance:Bank:luke]]"),
+		entry("shortcut", "Shared", "Bank shortcut", "", "[~"+baseId+"~]"),
+		entry("alias-alias", "", "Alias of alias", "", "[[Shared:Bank alias]]"),
+		entry("ambiguous", "", "Ambiguous", "", "[[Finance:Bank]]"),
+		entry("missing", "", "Missing", "", "[[Nowhere:Nothing]]"),
+		entry("cycle-1", "", "Cycle 1", "", "[[Cycle 2]]"),
+		entry("cycle-2", "", "Cycle 2", "", "[[Cycle 1]]"),
+		entry("self", "", "Self", "", "[[Self]]"),
+		entry("not-a-reference", "", "Brackets", "", "[[]]"),
+	} {
+		entries[e.Id()] = e
+	}
+
+	resolveReferences(entries)
+
+	testCases := []struct {
+		id       string
+		password string
+		kind     string
+	}{
+		{baseId, "hunter2", ""},
+		{"alias-uuid", "hunter2", vault.AliasOfField},
+		{"alias-title", "hunter2", vault.AliasOfField},
+		{"shortcut", "hunter2", vault.ShortcutOfField},
+		{"alias-alias", "hunter2", vault.AliasOfField},
+		{"ambiguous", "[[Finance:Bank]]", ""},
+		{"missing", "[[Nowhere:Nothing]]", ""},
+		{"cycle-1", "[[Cycle 2]]", ""},
+		{"cycle-2", "[[Cycle 1]]", ""},
+		{"self", "[[Self]]", ""},
+		{"not-a-reference", "[[]]", ""},
+	}
+
+	for _, tc := range testCases {
+		e := entries[tc.id]
+		assert.Equal(t, tc.password, e.Password().AsString(), tc.id)
+		for _, kind := range []string{vault.AliasOfField, vault.ShortcutOfField} {
+			if kind == tc.kind {
+				assert.Equal(t, vault.String(baseIdFor(tc.id, baseId)), e.Get(kind), tc.id)
+			} else {
+				assert.Nil(t, e.Get(kind), tc.id)
+			}
+		}
+	}
+
+	// Shortcuts take fields they don't have from their base entry, but aliases only their password.
+	assert.Equal(t, "https://bank.example.com/", entries["shortcut"].Url())
+	assert.Equal(t, "Bank shortcut", entries["shortcut"].Name())
+	assert.Equal(t, "", entries["alias-uuid"].Url())
+}
+
+func baseIdFor(id, baseId string) string {
+	if id == "alias-alias" {
+		return "alias-uuid"
+	}
+	return baseId
+}
@@ -30,15 +30,38 @@ func (d *DB) Close() error {
 }
 
 func (d *DB) UUID() uuid.UUID {
-	return d.hdr.uuid
+	return d.hdr.UUID
 }
 
 func (d *DB) Name() string {
-	return d.hdr.name
+	return d.hdr.Name
+}
+
+// Header returns the database's properties.
+func (d *DB) Header() Header {
+	return d.hdr
 }
 
 func (d *DB) Description() string {
-	return d.hdr.description
+	return d.hdr.Description
+}
+
+// EmptyGroups returns the groups that are stored in the database's header because they do not
+// contain any entries.
+func (d *DB) EmptyGroups() []vault.GroupPath {
+	return d.hdr.EmptyGroups
+}
+
+// YubikeySecret returns the HMAC-SHA1 secret that PasswordSafe stores in the header of
+// YubiKey-protected safes, if there is one.
+func (d *DB) YubikeySecret() ([]byte, bool) {
+	return d.hdr.YubikeySecret, d.hdr.YubikeySecret != nil
+}
+
+// Iterations returns the number of key stretching iterations used to derive the safe's key from
+// its password.
+func (d *DB) Iterations() uint {
+	return d.iterations
 }
 
 func (d *DB) Get(id string) (vault.Entry, bool) {
@@ -65,47 +88,88 @@ func (d *DB) Find(c func(vault.Entry) bool) []vault.Entry {
 }
 
 func decrypt(dbf *dbFile, password string) (*DB, error) {
+	plaintext, hmacKey, err := decryptCiphertext(dbf, password)
+	if err != nil {
+		return nil, err
+	}
+
+	err = authenticate(plaintext, hmacKey, dbf.Hmac())
+	if err != nil {
+		return nil, fmt.Errorf("authenticate: %w", err)
+	}
+
+	d, err := parse(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+	d.iterations = dbf.Iterations()
+
+	return d, nil
+}
+
+// decryptCiphertext returns a database's decrypted fields and the key for their HMAC.
+func decryptCiphertext(dbf *dbFile, password string) ([]byte, []byte, error) {
 	masterKey, err := crypto.DeriveKeySha256([]byte(password), dbf.Salt(), dbf.Iterations(), dbf.MasterKeyHash())
 	if err != nil {
-		return nil, fmt.Errorf("crypto.DeriveKeySha256: %w", err)
+		return nil, nil, fmt.Errorf("crypto.DeriveKeySha256: %w", err)
 	}
 
 	encryptionKey, err := twofish.DecryptECB(masterKey, dbf.EncryptionKey())
 	if err != nil {
-		return nil, fmt.Errorf("crypto.DecryptECB(encryptionKey): %w", err)
+		return nil, nil, fmt.Errorf("crypto.DecryptECB(encryptionKey): %w", err)
 	}
 
 	hmacKey, err := twofish.DecryptECB(masterKey, dbf.HmacKey())
 	if err != nil {
-		return nil, fmt.Errorf("crypto.DecryptECB(hmacKey): %w", err)
+		return nil, nil, fmt.Errorf("crypto.DecryptECB(hmacKey): %w", err)
 	}
 
 	plaintext, err := twofish.DecryptCBC(encryptionKey, dbf.Iv(), dbf.Ciphertext())
 	if err != nil {
-		return nil, fmt.Errorf("crypto.DecryptCBC: %w", err)
+		return nil, nil, fmt.Errorf("crypto.DecryptCBC: %w", err)
 	}
 
-	d, err := parse(plaintext, hmacKey, dbf.Hmac())
-	if err != nil {
-		return nil, fmt.Errorf("parse: %w", err)
+	return plaintext, hmacKey, nil
+}
+
+// authenticate checks a decrypted database's HMAC before any of its fields are interpreted.
+//
+// The HMAC covers the data of every field in the header and records, but not their lengths, types
+// or padding. Like PasswordSafe, this includes the (normally empty) data of the end of header and
+// end of record fields.
+//
+// https://github.com/pwsafe/pwsafe/blob/809a171cde0c7d984d81bfc911e5c4378d47cd7b/docs/formatV3.txt
+func authenticate(data, hmacKey, mac []byte) error {
+	h := hmac.New(sha256.New, hmacKey)
+	r := bytes.NewReader(data)
+	for {
+		f, err := readField(r)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("readField: %w", err)
+		}
+		h.Write(f.data)
 	}
 
-	return d, nil
+	if !hmac.Equal(mac, h.Sum(nil)) {
+		return fmt.Errorf("%w (HMAC mismatch)", vault.ErrIntegrity)
+	}
+	return nil
 }
 
-func parse(data, hmacKey, mac []byte) (*DB, error) {
-
-	h := hmac.New(sha256.New, hmacKey)
+// parse interprets a decrypted database. Its HMAC must already have been checked by authenticate.
+func parse(data []byte) (*DB, error) {
 	r := bytes.NewReader(data)
 
-	hdr, err := readRecord(r, h, endOfHeader)
+	hdr, err := readRecord(r, endOfHeader)
 	if err != nil {
 		return nil, fmt.Errorf("readRecord: %w", err)
 	}
 
 	var records []record
 	for {
-		recordFields, err := readRecord(r, h, endOfRecord)
+		recordFields, err := readRecord(r, endOfRecord)
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				break
@@ -116,13 +180,10 @@ func parse(data, hmacKey, mac []byte) (*DB, error) {
 		records = append(records, recordFields)
 	}
 
-	s := h.Sum(nil)
-	if !hmac.Equal(mac, s) {
-		return nil, fmt.Errorf("failed to authenticate data")
-	}
-
 	d := &DB{
-		entries: make(map[string]vault.Entry, 0),
+		entries:    make(map[string]vault.Entry, 0),
+		rawHeader:  hdr,
+		rawRecords: records,
 	}
 	var errs *multierror.Error
 
@@ -139,11 +200,22 @@ func parse(data, hmacKey, mac []byte) (*DB, error) {
 	for _, e := range entries {
 		d.entries[e.Id()] = e
 	}
+	resolveReferences(d.entries)
 
 	return d, errs.ErrorOrNil()
 }
 
+// DB is an open v3 database. Alongside the parsed header and entries, it keeps the header and
+// records exactly as they were read, including fields this package doesn't understand, so that
+// nothing is lost if the database is written back.
+//
+// Only rawHeader and rawRecords are lossless. An entry holds each unknown record field under a
+// key such as "0x7e", so if a record repeats an unknown field type, the entry only has the last
+// one.
 type DB struct {
-	hdr     header
-	entries map[string]vault.Entry
+	hdr        Header
+	entries    map[string]vault.Entry
+	iterations uint
+	rawHeader  record
+	rawRecords []record
 }
@@ -1,6 +1,12 @@
 package v3
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
 	"testing"
 	"time"
 
@@ -25,13 +31,31 @@ func TestOpenDb(t *testing.T) {
 	assert.Equal(t, "Test database", db.Name())
 	assert.Equal(t, "For testing purposes only!", db.Description())
 	assert.Len(t, db.List(), 9)
+	assert.Equal(t, uint(1353000), db.Iterations())
 
-	assert.Equal(t, time.Date(2023, 4, 2, 19, 31, 36, 0, time.UTC), db.hdr.lastSavedAt.UTC())
-	assert.Equal(t, "Password Safe V3.58", db.hdr.lastSavedByWhat)
-	assert.Equal(t, "luke", db.hdr.lastSavedByWhom)
-	assert.Equal(t, "OWENS-PC", db.hdr.lastSavedOnHost)
-	assert.Len(t, db.hdr.emptyGroups, 3)
+	assert.Equal(t, time.Date(2023, 4, 2, 19, 31, 36, 0, time.UTC), db.hdr.LastSavedAt.UTC())
+	assert.Equal(t, "Password Safe V3.58", db.hdr.LastSavedByWhat)
+	assert.Equal(t, "luke", db.hdr.LastSavedByWhom)
+	assert.Equal(t, "OWENS-PC", db.hdr.LastSavedOnHost)
+	assert.Equal(t, []vault.GroupPath{{"Almost empty group", "Empty subgroup"}, {"Empty group"}, {"Empty group 2"}},
+		db.EmptyGroups())
 
+	h := db.Header()
+	assert.Equal(t, "3.14", h.VersionString())
+	assert.Equal(t, []Preference{{'B', 4, "1"}, {'B', 28, "1"}, {'B', 29, "1"}, {'I', 11, "2"}, {'I', 12, "10"}, {'S', 3, "luke"}},
+		h.Preferences)
+	assert.Equal(t, []bool{true, true, true, true, true, true, true, true}, h.TreeDisplayStatus)
+	assert.Equal(t, []uuid.UUID{
+		uuid.MustParse("18f02841-6278-4b04-b357-d0a8b783e142"),
+		uuid.MustParse("a2ae8282-c20c-465c-ba66-ea40970e2d72"),
+		uuid.MustParse("bcdc6634-9e1a-4657-8cbf-36a4bc1a09cd"),
+	}, h.RecentlyUsedEntries)
+	assert.Equal(t, []PasswordPolicy{{Name: "ßĕţťėŕ", Flags: 0xf000, Length: 24, Symbols: `+-=_@#$%^&;:,.<>/~\[](){}?!|*`}},
+		h.NamedPasswordPolicies)
+	assert.Equal(t, time.Date(2023, 2, 12, 12, 44, 51, 0, time.UTC), h.MasterPasswordChangedAt.UTC())
+
+	_, found := db.YubikeySecret()
+	assert.False(t, found)
 	err = db.Close()
 	assert.Nil(t, err)
 }
@@ -40,7 +64,10 @@ func TestOpenDb_oldTimestampFormat(t *testing.T) {
 	db, err := OpenDb("testdata/test-3.08.psafe3", password)
 	assert.Nil(t, err)
 
-	assert.Equal(t, time.Date(2023, 4, 2, 19, 43, 53, 0, time.UTC), db.hdr.lastSavedAt.UTC())
+	assert.Equal(t, time.Date(2023, 4, 2, 19, 43, 53, 0, time.UTC), db.hdr.LastSavedAt.UTC())
+	assert.Equal(t, "3.01", db.hdr.VersionString())
+	assert.Equal(t, "luke", db.hdr.LastSavedByWhom)
+	assert.Equal(t, "OWENS-PC", db.hdr.LastSavedOnHost)
 
 	err = db.Close()
 	assert.Nil(t, err)
@@ -50,20 +77,86 @@ func TestOpenDb_errors(t *testing.T) {
 	testCases := []struct {
 		dbFile   string
 		password string
+		expected error
 	}{
-		{"testdata/nonexistent", password},
-		{"testdata/test-bad-hmac.psafe3", password},
-		{"testdata/test-short-ciphertext.psafe3", password},
-		{testDb, "12345"},
+		{"testdata/nonexistent", password, os.ErrNotExist},
+		{"testdata/test-bad-hmac.psafe3", password, vault.ErrIntegrity},
+		{"testdata/test-tampered-ciphertext.psafe3", password, vault.ErrIntegrity},
+		{"testdata/test-tampered-hmac.psafe3", password, vault.ErrIntegrity},
+		{"testdata/test-truncated-record.psafe3", password, vault.ErrIntegrity},
+		{"testdata/test-short-ciphertext.psafe3", password, vault.ErrTruncated},
+		{testDb, "12345", vault.ErrIncorrectPassword},
 	}
 
 	for _, tc := range testCases {
 		db, err := OpenDb(tc.dbFile, tc.password)
-		assert.NotNil(t, err)
+		assert.ErrorIs(t, err, tc.expected, tc.dbFile)
 		assert.Nil(t, db)
 	}
 }
 
+func Test_authenticate(t *testing.T) {
+	hmacKey := []byte("hmac key")
+	data := append(encodeField(databaseNameField, []byte("Test database")), encodeField(endOfHeader, nil)...)
+	data = append(data, encodeField(0x03, []byte("name"))...)
+	data = append(data, encodeField(endOfRecord, nil)...)
+
+	h := hmac.New(sha256.New, hmacKey)
+	h.Write([]byte("Test database"))
+	h.Write([]byte("name"))
+	mac := h.Sum(nil)
+
+	assert.Nil(t, authenticate(data, hmacKey, mac))
+
+	// Padding is not covered by the HMAC.
+	padded := bytes.Clone(data)
+	padded[2*chunkSize-1] ^= 0xff
+	assert.Nil(t, authenticate(padded, hmacKey, mac))
+
+	tampered := bytes.Clone(data)
+	tampered[5] ^= 0x01
+	assert.ErrorIs(t, authenticate(tampered, hmacKey, mac), vault.ErrIntegrity)
+
+	assert.ErrorIs(t, authenticate(data[:len(data)-chunkSize+2], hmacKey, mac), vault.ErrIntegrity)
+	assert.ErrorIs(t, authenticate(data, []byte("wrong key"), mac), vault.ErrIntegrity)
+}
+
+func TestDB_roundTrip(t *testing.T) {
+	dbf, err := readDbFile("testdata/test-3.08.psafe3")
+	assert.Nil(t, err)
+	plaintext, _, err := decryptCiphertext(dbf, password)
+	assert.Nil(t, err)
+
+	d, err := parse(plaintext)
+	assert.Nil(t, err)
+	assert.Len(t, d.hdr.unknownFields, 0)
+
+	// Padding is random, so compare everything else.
+	var encoded []byte
+	for _, r := range append([]record{d.rawHeader}, d.rawRecords...) {
+		for _, f := range append(r.fields, r.end) {
+			encoded = append(encoded, encodeField(f.typ, f.data)...)
+		}
+	}
+	assert.Equal(t, len(plaintext), len(encoded))
+	assert.Equal(t, readAllFields(t, plaintext), readAllFields(t, encoded))
+}
+
+func readAllFields(t *testing.T, data []byte) []field {
+	var fields []field
+	r := bytes.NewReader(data)
+	for {
+		f, err := readField(r)
+		if errors.Is(err, io.EOF) {
+			return fields
+		}
+		if !assert.Nil(t, err) {
+			return fields
+		}
+		fields = append(fields, f)
+	}
+}
+
 func TestDB_Get(t *testing.T) {
 	testCases := []struct {
 		id       string
@@ -106,6 +199,58 @@ func TestDB_Get(t *testing.T) {
 	}
 }
 
+func TestDB_groups(t *testing.T) {
+	db, _ := OpenDb(testDb, password)
+	defer closeDb(db)
+
+	e, _ := db.Get("48e68cc4-e207-438f-8f44-54fa9307dfea")
+	assert.Equal(t, `Misc/1\/2`, e.Group())
+	assert.Equal(t, vault.GroupPath{"Misc", "1/2"}, e.GroupPath())
+
+	e, _ = db.Get("2deb74d0-79b3-4f62-8a89-56dd843d0ce9")
+	assert.Equal(t, vault.GroupPath{"Misc", "1"}, e.GroupPath())
+	assert.Equal(t, "1.1.1", e.Name())
+}
+
+func TestDB_Get_passwordExpiryInterval(t *testing.T) {
+	db, _ := OpenDb(testDb, password)
+	defer closeDb(db)
+
+	e, _ := db.Get("2deb74d0-79b3-4f62-8a89-56dd843d0ce9")
+	assert.Equal(t, vault.Duration(365*24*time.Hour), e.Get(vault.PasswordExpiryIntervalField))
+}
+
+func TestDB_Get_actions(t *testing.T) {
+	db, _ := OpenDb(testDb, password)
+	defer closeDb(db)
+
+	e, _ := db.Get("2deb74d0-79b3-4f62-8a89-56dd843d0ce9")
+	assert.Equal(t, `\u\n\p\n`, e.Autotype())
+	assert.Equal(t, "cmd /d /c dir c:", e.RunCommand())
+	assert.Equal(t, vault.String("copyPasswordMinimize"), e.Get(vault.DoubleClickActionField))
+	assert.Equal(t, vault.String("run"), e.Get(vault.ShiftDoubleClickActionField))
+}
+
+func Test_asDoubleClickAction(t *testing.T) {
+	testCases := []struct {
+		data      []byte
+		expected  vault.Value
+		expectErr bool
+	}{
+		{[]byte{0x00, 0x00}, vault.String("copyPassword"), false},
+		{[]byte{0x09, 0x00}, vault.String("sendEmail"), false},
+		{[]byte{0xff, 0x00}, vault.String("default"), false},
+		{[]byte{0x0a, 0x00}, vault.Int(10), false},
+		{[]byte{0x00}, nil, true},
+	}
+
+	for _, tc := range testCases {
+		v, err := asDoubleClickAction(tc.data)
+		assert.Equal(t, tc.expected, v)
+		assert.Equal(t, tc.expectErr, err != nil)
+	}
+}
+
 func TestDB_List(t *testing.T) {
 	db, _ := OpenDb(testDb, password)
 	defer closeDb(db)
@@ -1,11 +1,18 @@
 package v3
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/sha256"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"os"
+
+	"notpass-go/internal/backend/passwordsafe/crypto/twofish"
+	"notpass-go/pkg/vault"
 )
 
 const Magic = tag
@@ -32,40 +39,84 @@ type dbFile struct {
 }
 
 func readDbFile(dbPath string) (*dbFile, error) {
-	data, err := os.ReadFile(dbPath)
+	f, err := os.Open(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("os.Open: %w", err)
+	}
+	defer func() {
+		err := f.Close()
+		if err != nil {
+			log.Printf("failed to close \"%s\": %v", dbPath, err)
+		}
+	}()
+
+	dbf, err := decodeDbFile(bufio.NewReader(f))
 	if err != nil {
-		return nil, fmt.Errorf("os.ReadFile: %w", err)
+		return nil, fmt.Errorf("decodeDbFile: %w", err)
 	}
+	return dbf, nil
+}
 
-	dbf := dbFile{
-		ciphertext: make([]byte, len(data)-prefixLen-suffixLen),
+// decodeDbFile reads a database file's unencrypted fields. The end of the ciphertext is found by
+// reading it a block at a time until the EOF block, as PasswordSafe does, so the ciphertext is
+// never allocated based on lengths claimed by the file.
+func decodeDbFile(r io.Reader) (*dbFile, error) {
+	var dbf dbFile
+	err := dbf.readPrefix(r)
+	if err != nil {
+		return nil, err
 	}
 
-	r := bytes.NewReader(data)
-	_, _ = r.Read(dbf.tag[:])
-	_, _ = r.Read(dbf.salt[:])
-	_, _ = r.Read(dbf.iter[:])
-	_, _ = r.Read(dbf.hp[:])
-	_, _ = r.Read(dbf.b1[:])
-	_, _ = r.Read(dbf.b2[:])
-	_, _ = r.Read(dbf.b3[:])
-	_, _ = r.Read(dbf.b4[:])
-	_, _ = r.Read(dbf.iv[:])
-	_, _ = r.Read(dbf.ciphertext)
-	_, _ = r.Read(dbf.eof[:])
-	_, _ = r.Read(dbf.hmac[:])
-
-	if !bytes.Equal(dbf.tag[:], []byte(tag)) {
-		return nil, fmt.Errorf("invalid PasswordSafe db file (expected tag: %s)", tag)
+	block := make([]byte, twofish.BlockSize)
+	for {
+		err = readFull(r, block)
+		if err != nil {
+			return nil, fmt.Errorf("%w (expected eof: %s)", err, eof)
+		}
+		if bytes.Equal(block, []byte(eof)) {
+			break
+		}
+		dbf.ciphertext = append(dbf.ciphertext, block...)
 	}
+	copy(dbf.eof[:], block)
 
-	if !bytes.Equal(dbf.eof[:], []byte(eof)) {
-		return nil, fmt.Errorf("invalid PasswordSafe db file (expected eof: %s)", eof)
+	err = readFull(r, dbf.hmac[:])
+	if err != nil {
+		return nil, err
 	}
 
 	return &dbf, nil
 }
 
+// readPrefix reads the fields that precede the ciphertext.
+func (d *dbFile) readPrefix(r io.Reader) error {
+	err := readFull(r, d.tag[:])
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(d.tag[:], []byte(tag)) {
+		return fmt.Errorf("%w (expected tag: %s)", vault.ErrUnsupportedFormat, tag)
+	}
+
+	for _, b := range [][]byte{d.salt[:], d.iter[:], d.hp[:], d.b1[:], d.b2[:], d.b3[:], d.b4[:], d.iv[:]} {
+		err = readFull(r, b)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readFull(r io.Reader, b []byte) error {
+	_, err := io.ReadFull(r, b)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return vault.ErrTruncated
+	} else if err != nil {
+		return fmt.Errorf("io.ReadFull: %w", err)
+	}
+	return nil
+}
+
 func (d *dbFile) Salt() []byte {
 	return d.salt[:]
 }
@@ -100,7 +151,6 @@ func (d *dbFile) Ciphertext() []byte {
 
 const (
 	prefixLen = len(tag) + 32 + 4 + sha256.Size + 16*4 + 16
-	suffixLen = len(eof) + sha256.Size
 	tag       = "PWS3"
 	eof       = "PWS3-EOFPWS3-EOF"
 )
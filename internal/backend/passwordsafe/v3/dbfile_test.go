@@ -1,11 +1,17 @@
 package v3
 
 import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"notpass-go/internal/backend/passwordsafe/crypto/twofish"
 	"notpass-go/internal/testutil"
+	"notpass-go/pkg/vault"
 )
 
 func Test_readDbFile(t *testing.T) {
@@ -36,17 +42,51 @@ func Test_readDbFile(t *testing.T) {
 }
 
 func Test_readDbFile_errors(t *testing.T) {
+	truncated := filepath.Join(t.TempDir(), "truncated.psafe3")
+	data, err := os.ReadFile("testdata/test.psafe3")
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(truncated, data[:100], 0600))
+
 	testCases := []struct {
-		dbFile string
+		dbFile   string
+		expected error
 	}{
-		{"testdata/nonexistent"},
-		{"testdata/test-bad-tag.psafe3"},
-		{"testdata/test-bad-eof.psafe3"},
+		{"testdata/nonexistent", os.ErrNotExist},
+		{"testdata/test-bad-tag.psafe3", vault.ErrUnsupportedFormat},
+		{"testdata/test-bad-eof.psafe3", vault.ErrTruncated},
+		{"testdata/test-short-ciphertext.psafe3", vault.ErrTruncated},
+		{truncated, vault.ErrTruncated},
 	}
 
 	for _, tc := range testCases {
 		dbf, err := readDbFile(tc.dbFile)
-		assert.NotNil(t, err)
+		assert.ErrorIs(t, err, tc.expected, tc.dbFile)
 		assert.Nil(t, dbf)
 	}
 }
+
+func FuzzDecodeDbFile(f *testing.F) {
+	for _, name := range []string{"test.psafe3", "test-empty.psafe3", "test-bad-eof.psafe3", "test-short-ciphertext.psafe3"} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		dbf, err := decodeDbFile(bytes.NewReader(data))
+		if err != nil {
+			if !errors.Is(err, vault.ErrTruncated) && !errors.Is(err, vault.ErrUnsupportedFormat) {
+				t.Errorf("unexpected error: %v", err)
+			}
+			return
+		}
+		if len(dbf.Ciphertext())%twofish.BlockSize != 0 {
+			t.Errorf("ciphertext is not a whole number of blocks: %d bytes", len(dbf.Ciphertext()))
+		}
+		if len(dbf.Ciphertext()) > len(data) {
+			t.Errorf("ciphertext is longer than the file")
+		}
+	})
+}
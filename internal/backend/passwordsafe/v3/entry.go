@@ -2,12 +2,18 @@ package v3
 
 import (
 	"fmt"
+	"log"
 
+	"github.com/google/uuid"
 	"github.com/hashicorp/go-multierror"
 
+	"notpass-go/pkg/sensitive"
 	"notpass-go/pkg/vault"
 )
 
+// parseEntries decodes records into entries. Only an undecodable UUID is an error. Any other field
+// that doesn't decode is logged as a warning and kept as its raw bytes, which are secret if the
+// field is, so that one odd field doesn't stop the whole safe from opening.
 func parseEntries(records []record) ([]vault.Entry, error) {
 	var entries []vault.Entry
 	var errs *multierror.Error
@@ -17,13 +23,16 @@ func parseEntries(records []record) ([]vault.Entry, error) {
 		for _, f := range r.fields {
 			if x, ok := fieldMap[f.typ]; ok {
 				value, err := x.parse(f.data)
-				if err != nil {
-					errs = multierror.Append(errs, err)
+				if err != nil && x.name == vault.IdField {
+					errs = multierror.Append(errs, fmt.Errorf("field 0x%02x (%s): %w", f.typ, x.name, err))
+				} else if err != nil {
+					log.Printf("warning: record %s: field 0x%02x (%s): %v; keeping its raw value", recordId(r), f.typ, x.name, err)
+					value = rawValue(x.name, f.data)
 				}
 				e = e.With(x.name, value)
 			} else {
 				k := fmt.Sprintf("0x%02x", f.typ)
-				v, _ := asHexString(f.data)
+				v, _ := asBytes(f.data)
 				e = e.With(k, v)
 			}
 		}
@@ -33,43 +42,67 @@ func parseEntries(records []record) ([]vault.Entry, error) {
 	return entries, errs.ErrorOrNil()
 }
 
+// recordId returns the record's UUID for messages, or "?" if it doesn't have a valid one.
+func recordId(r record) string {
+	for _, f := range r.fields {
+		if f.typ == 0x01 {
+			if id, err := uuid.FromBytes(f.data); err == nil {
+				return id.String()
+			}
+		}
+	}
+	return "?"
+}
+
+func rawValue(name string, data []byte) vault.Value {
+	if t, ok := vault.FieldType(name); ok && t.IsSecret() {
+		return sensitive.Bytes(data)
+	}
+	return vault.Bytes(data)
+}
+
 var fieldMap = map[byte]struct {
 	name  string
 	parse func([]byte) (vault.Value, error)
 }{
 	0x01: {vault.IdField, asUUIDString},
-	0x02: {vault.GroupField, asString},
+	0x02: {vault.GroupField, asGroup},
 	0x03: {vault.NameField, asString},
 	0x04: {vault.UsernameField, asString},
 	0x05: {vault.NoteField, asSensitiveString},
 	0x06: {vault.PasswordField, asSensitiveString},
-	0x07: {"creationTime", asTimestamp},
-	0x08: {"passwordModificationTime", asTimestamp},
-	0x09: {"lastAccessTime", asTimestamp},
-	0x0a: {"passwordExpiryTime", asTimestamp},
-	0x0c: {"lastModificationTime", asTimestamp},
+	0x07: {vault.CreationTimeField, asTimestamp},
+	0x08: {vault.PasswordModificationTimeField, asTimestamp},
+	0x09: {vault.LastAccessTimeField, asTimestamp},
+	0x0a: {vault.PasswordExpiryTimeField, asTimestamp},
+	0x0c: {vault.LastModificationTimeField, asTimestamp},
 	0x0d: {vault.UrlField, asString},
-	0x0e: {"autotype", asHexString},
-	0x0f: {"passwordHistory", asHexString},
-	0x10: {"passwordPolicy", asHexString},
-	0x11: {"passwordExpiryInterval", asHexString},
-	0x12: {"runCommand", asHexString},
-	0x13: {"doubleClickAction", asHexString},
-	0x14: {"email", asString},
-	0x15: {"protectedEntry", asHexString},
-	0x16: {"ownSymbolsForPassword", asHexString},
-	0x17: {"shiftDoubleClickAction", asHexString},
-	0x18: {"passwordPolicyName", asHexString},
-	0x19: {"entryKeyboardShortcut", asHexString},
+	0x0e: {vault.AutotypeField, asString},
+	0x0f: {vault.PasswordHistoryField, asPasswordHistory},
+	0x10: {vault.PasswordPolicyField, asString},
+	0x11: {vault.PasswordExpiryIntervalField, asDays},
+	0x12: {vault.RunCommandField, asString},
+	0x13: {vault.DoubleClickActionField, asDoubleClickAction},
+	0x14: {vault.EmailField, asString},
+	0x15: {vault.ProtectedField, asBool},
+	0x16: {vault.PasswordSymbolsField, asString},
+	0x17: {vault.ShiftDoubleClickActionField, asDoubleClickAction},
+	0x18: {vault.PasswordPolicyNameField, asString},
+	0x19: {vault.KeyboardShortcutField, asBytes},
+
+	0x1b: {vault.TwoFactorKeyField, asBase32SensitiveString},
+	0x21: {vault.TotpAlgorithmField, asTotpAlgorithm},
+	0x22: {vault.TotpDigitsField, asInt},
+	0x23: {vault.TotpPeriodField, asSeconds},
+	0x24: {vault.TotpStartTimeField, asTimestamp},
+
+	0x1c: {vault.CreditCardNumberField, asSensitiveString},
+	0x1d: {vault.CreditCardExpiryField, asSensitiveString},
+	0x1e: {vault.CreditCardCVVField, asSensitiveString},
+	0x1f: {vault.CreditCardPINField, asSensitiveString},
+	0x20: {vault.QRCodeField, asSensitiveString},
 
-	// None of these are currently implemented by PasswordSafe.
-	0x1b: {"twoFactorKeyField", asHexString},
-	0x1c: {"creditCardNumberField", asHexString},
-	0x1d: {"creditCardExpirationField", asHexString},
-	0x1e: {"creditCardCVVField", asHexString},
-	0x1f: {"creditCardPINField", asHexString},
-	0x20: {"qrCodeField", asHexString},
-	0xdf: {"unknownField", asHexString},
+	0xdf: {"unknownField", asBytes},
 }
 
 const (
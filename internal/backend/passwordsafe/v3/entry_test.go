@@ -0,0 +1,164 @@
+package v3
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/pkg/sensitive"
+	"notpass-go/pkg/vault"
+)
+
+func Test_parseEntries_undecodableFields(t *testing.T) {
+	id := []byte{0xa6, 0x62, 0xb6, 0x55, 0x2b, 0x16, 0x4e, 0x37, 0xb5, 0xa7, 0x78, 0x9c, 0xaa, 0x78, 0x28, 0xd0}
+	records := []record{{fields: []field{
+		{0x01, id},
+		{0x07, []byte{0x01}},
+		{0x11, []byte{0x5a, 0x00}},
+	}}}
+
+	entries, err := parseEntries(records)
+
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	e := entries[0]
+	assert.Equal(t, "a662b655-2b16-4e37-b5a7-789caa7828d0", e.Id())
+	assert.Equal(t, vault.Bytes{0x01}, e.Get(vault.CreationTimeField))
+	assert.Equal(t, vault.Bytes{0x5a, 0x00}, e.Get(vault.PasswordExpiryIntervalField))
+}
+
+func Test_parseEntries_undecodableId(t *testing.T) {
+	_, err := parseEntries([]record{{fields: []field{{0x01, []byte{0x01}}}}})
+
+	assert.NotNil(t, err)
+}
+
+func Test_parseEntries_totp(t *testing.T) {
+	records := []record{{fields: []field{
+		{0x01, []byte{0xa6, 0x62, 0xb6, 0x55, 0x2b, 0x16, 0x4e, 0x37, 0xb5, 0xa7, 0x78, 0x9c, 0xaa, 0x78, 0x28, 0xd0}},
+		{0x1b, []byte("12345678901234567890")},
+		{0x21, []byte{0x00}},
+		{0x22, []byte{0x08}},
+		{0x23, []byte{0x3c}},
+	}}}
+
+	entries, err := parseEntries(records)
+
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	e := entries[0]
+	assert.Equal(t, sensitive.String("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"), e.Get(vault.TwoFactorKeyField))
+	assert.Equal(t, vault.String("SHA1"), e.Get(vault.TotpAlgorithmField))
+	assert.Equal(t, vault.Int(8), e.Get(vault.TotpDigitsField))
+	assert.Equal(t, vault.Duration(time.Minute), e.Get(vault.TotpPeriodField))
+	assert.Nil(t, e.WithoutSecrets().Get(vault.TwoFactorKeyField))
+}
+
+func Test_parseEntries_unsupportedTotp(t *testing.T) {
+	records := []record{{fields: []field{
+		{0x01, []byte{0xa6, 0x62, 0xb6, 0x55, 0x2b, 0x16, 0x4e, 0x37, 0xb5, 0xa7, 0x78, 0x9c, 0xaa, 0x78, 0x28, 0xd0}},
+		{0x1b, []byte("12345678901234567890")},
+		{0x21, []byte{0x01}},
+		{0x22, []byte{0x08, 0x00}},
+		{0x23, []byte{}},
+	}}}
+
+	entries, err := parseEntries(records)
+
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	e := entries[0]
+	assert.Equal(t, vault.Bytes{0x01}, e.Get(vault.TotpAlgorithmField))
+	assert.Equal(t, vault.Bytes{0x08, 0x00}, e.Get(vault.TotpDigitsField))
+	assert.Equal(t, vault.Bytes{}, e.Get(vault.TotpPeriodField))
+}
+
+func Test_parseEntries_cardAndProtected(t *testing.T) {
+	records := []record{{fields: []field{
+		{0x01, []byte{0xa6, 0x62, 0xb6, 0x55, 0x2b, 0x16, 0x4e, 0x37, 0xb5, 0xa7, 0x78, 0x9c, 0xaa, 0x78, 0x28, 0xd0}},
+		{0x14, []byte("finance@example.com")},
+		{0x15, []byte{0x01}},
+		{0x1c, []byte("4111 1111 1111 1111")},
+		{0x1d, []byte("04/27")},
+		{0x1e, []byte("123")},
+		{0x1f, []byte("0000")},
+	}}}
+
+	entries, err := parseEntries(records)
+
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	e := entries[0]
+	assert.Equal(t, "finance@example.com", e.Email())
+	assert.True(t, e.Protected())
+	assert.ErrorIs(t, e.CheckEditable(), vault.ErrProtectedEntry)
+	assert.Equal(t, sensitive.String("4111 1111 1111 1111"), e.Get(vault.CreditCardNumberField))
+	assert.Equal(t, sensitive.String("04/27"), e.CreditCardExpiry())
+	assert.Equal(t, sensitive.String("123"), e.CreditCardCVV())
+	assert.Equal(t, sensitive.String("0000"), e.CreditCardPIN())
+
+	s := e.WithoutSecrets()
+	assert.Equal(t, "finance@example.com", s.Email())
+	for _, f := range []string{vault.CreditCardNumberField, vault.CreditCardExpiryField, vault.CreditCardCVVField, vault.CreditCardPINField} {
+		assert.Nil(t, s.Get(f), f)
+	}
+}
+
+func Test_parseEntries_undecodableProtected(t *testing.T) {
+	records := []record{{fields: []field{
+		{0x01, []byte{0xa6, 0x62, 0xb6, 0x55, 0x2b, 0x16, 0x4e, 0x37, 0xb5, 0xa7, 0x78, 0x9c, 0xaa, 0x78, 0x28, 0xd0}},
+		{0x15, []byte{0x00, 0x00}},
+	}}}
+
+	entries, err := parseEntries(records)
+
+	assert.Nil(t, err)
+	e := entries[0]
+	assert.Equal(t, vault.Bytes{0x00, 0x00}, e.Get(vault.ProtectedField))
+	assert.True(t, e.Protected())
+	assert.ErrorIs(t, e.CheckEditable(), vault.ErrProtectedEntry)
+}
+
+func Test_asPasswordHistory(t *testing.T) {
+	v, err := asPasswordHistory([]byte("10302" + "63e8e1ab0007hunter1" + "640ffc1b0007hünter2"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, vault.SecretList{
+		vault.List{vault.Timestamp(time.Unix(0x63e8e1ab, 0)), sensitive.String("hunter1")},
+		vault.List{vault.Timestamp(time.Unix(0x640ffc1b, 0)), sensitive.String("hünter2")},
+	}, v)
+
+	_, err = asPasswordHistory([]byte("10302" + "63e8e1ab0007hunter1"))
+	assert.NotNil(t, err)
+}
+
+func Test_fieldMap_registeredTypes(t *testing.T) {
+	for typ, f := range fieldMap {
+		expected, ok := vault.FieldType(f.name)
+		if !ok {
+			continue
+		}
+		// Try data of each length that some field requires, and the valid empty password history.
+		for _, data := range [][]byte{make([]byte, 1), make([]byte, 2), make([]byte, 4), make([]byte, 16), []byte("00000")} {
+			if v, err := f.parse(data); err == nil {
+				assert.Equal(t, expected, vault.TypeOf(v), "0x%02x %s", typ, f.name)
+				break
+			}
+		}
+	}
+}
+
+func Test_parseEntries_undecodablePasswordHistory(t *testing.T) {
+	records := []record{{fields: []field{
+		{0x01, []byte{0xa6, 0x62, 0xb6, 0x55, 0x2b, 0x16, 0x4e, 0x37, 0xb5, 0xa7, 0x78, 0x9c, 0xaa, 0x78, 0x28, 0xd0}},
+		{0x0f, []byte("1ff01garbage")},
+	}}}
+
+	entries, err := parseEntries(records)
+
+	assert.Nil(t, err)
+	e := entries[0]
+	assert.Equal(t, sensitive.Bytes("1ff01garbage"), e.Get(vault.PasswordHistoryField))
+	assert.Nil(t, e.WithoutSecrets().Get(vault.PasswordHistoryField))
+}
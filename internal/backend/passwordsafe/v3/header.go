@@ -5,29 +5,75 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"log"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/hashicorp/go-multierror"
 
 	"notpass-go/internal/backend/passwordsafe/util"
+	"notpass-go/pkg/vault"
 )
 
-type header struct {
-	version         uint16
-	uuid            uuid.UUID
-	name            string
-	description     string
-	lastSavedAt     time.Time
-	lastSavedByWhat string
-	lastSavedByWhom string
-	lastSavedOnHost string
-	emptyGroups     []string
-	ignoredFields   map[byte][]byte
+// Header holds a database's properties.
+//
+// Version is the format version, with the major version in the high byte. LastSavedByWhom and
+// LastSavedOnHost are also filled in from the deprecated combined field written by older versions
+// of PasswordSafe. TreeDisplayStatus records which groups were expanded, in display order.
+// YubikeySecret is the HMAC-SHA1 secret of the YubiKey that protects the database, if any.
+type Header struct {
+	Version                 uint16
+	UUID                    uuid.UUID
+	Preferences             []Preference
+	TreeDisplayStatus       []bool
+	LastSavedAt             time.Time
+	LastSavedByWhat         string
+	LastSavedByWhom         string
+	LastSavedOnHost         string
+	Name                    string
+	Description             string
+	Filters                 string
+	RecentlyUsedEntries     []uuid.UUID
+	NamedPasswordPolicies   []PasswordPolicy
+	EmptyGroups             []vault.GroupPath
+	YubikeySecret           []byte
+	MasterPasswordChangedAt time.Time
+
+	// unknownFields are the fields this package doesn't know, or couldn't decode, in order.
+	unknownFields []field
+}
+
+// VersionString formats the header's format version as PasswordSafe does, e.g. "3.13".
+func (h Header) VersionString() string {
+	return fmt.Sprintf("%d.%02d", h.Version>>8, h.Version&0xff)
+}
+
+// Preference is a database preference that has been changed from its default. Type is 'B' for
+// booleans, 'I' for integers and 'S' for strings. Id is PasswordSafe's identifier for the
+// preference.
+type Preference struct {
+	Type  byte
+	Id    int
+	Value string
 }
 
-func parseHeader(r record) (header, error) {
-	h := header{}
+// PasswordPolicy is a named password policy, used to generate passwords for entries.
+type PasswordPolicy struct {
+	Name         string
+	Flags        uint16
+	Length       int
+	MinLowercase int
+	MinUppercase int
+	MinDigits    int
+	MinSymbols   int
+	Symbols      string
+}
+
+func parseHeader(r record) (Header, error) {
+	h := Header{}
 	var errs *multierror.Error
 
 	for _, f := range r.fields {
@@ -40,51 +86,257 @@ func parseHeader(r record) (header, error) {
 	return h, errs.ErrorOrNil()
 }
 
-func (h *header) set(typ byte, data []byte) error {
+func (h *Header) set(typ byte, data []byte) error {
 	var err error
 	switch typ {
 	case versionField:
-		h.version = binary.LittleEndian.Uint16(data)
+		if len(data) != 2 {
+			err = errors.New("expected 2 bytes for version")
+		} else {
+			h.Version = binary.LittleEndian.Uint16(data)
+		}
 	case headerUuidField:
-		h.uuid, err = uuid.FromBytes(data)
+		h.UUID, err = uuid.FromBytes(data)
 		if err != nil {
 			err = fmt.Errorf("uuid.FromBytes: %w", err)
 		}
+	case nonDefaultPreferencesField:
+		prefs, perr := parsePreferences(string(data))
+		if perr != nil {
+			h.keepUndecoded(typ, data, fmt.Errorf("parsePreferences: %w", perr))
+		} else {
+			h.Preferences = prefs
+		}
+	case treeDisplayStatusField:
+		h.TreeDisplayStatus = make([]bool, len(data))
+		for i, b := range data {
+			h.TreeDisplayStatus[i] = b == '1'
+		}
 	case lastSavedAtField:
-		// https://github.com/pwsafe/pwsafe/blob/809a171cde0c7d984d81bfc911e5c4378d47cd7b/docs/formatV3.txt#L206
-		if len(data) == 4 {
-			h.lastSavedAt, err = util.ParseTimestamp(data)
-		} else if len(data) == 8 {
-			var ts []byte
-			ts, err = hex.DecodeString(string(data))
-			for i, j := 0, len(ts)-1; i < j; i, j = i+1, j-1 {
-				ts[i], ts[j] = ts[j], ts[i]
-			}
-			h.lastSavedAt, err = util.ParseTimestamp(ts)
+		h.LastSavedAt, err = parseHeaderTimestamp(data)
+	case lastSavedByField:
+		// Written by PasswordSafe 3.00 - 3.02 as the length of the user name in 4 hex digits,
+		// followed by the user name and the host name.
+		whom, host, perr := parseLastSavedBy(string(data))
+		if perr != nil {
+			h.keepUndecoded(typ, data, fmt.Errorf("parseLastSavedBy: %w", perr))
 		} else {
-			err = errors.New("expected 4 or 8 bytes for timestamp")
+			if h.LastSavedByWhom == "" {
+				h.LastSavedByWhom = whom
+			}
+			if h.LastSavedOnHost == "" {
+				h.LastSavedOnHost = host
+			}
 		}
 	case lastSavedByWhatField:
-		h.lastSavedByWhat = string(data)
+		h.LastSavedByWhat = string(data)
 	case lastSavedByWhomField:
-		h.lastSavedByWhom = string(data)
+		h.LastSavedByWhom = string(data)
 	case lastSavedOnHostField:
-		h.lastSavedOnHost = string(data)
+		h.LastSavedOnHost = string(data)
 	case databaseNameField:
-		h.name = string(data)
+		h.Name = string(data)
 	case databaseDescriptionField:
-		h.description = string(data)
+		h.Description = string(data)
+	case databaseFiltersField:
+		h.Filters = string(data)
+	case recentlyUsedEntriesField:
+		ids, perr := parseRecentlyUsedEntries(string(data))
+		if perr != nil {
+			h.keepUndecoded(typ, data, fmt.Errorf("parseRecentlyUsedEntries: %w", perr))
+		} else {
+			h.RecentlyUsedEntries = ids
+		}
+	case namedPasswordPoliciesField:
+		policies, perr := parsePasswordPolicies(string(data))
+		if perr != nil {
+			h.keepUndecoded(typ, data, fmt.Errorf("parsePasswordPolicies: %w", perr))
+		} else {
+			h.NamedPasswordPolicies = policies
+		}
+	case yubicoField:
+		// PasswordSafe keeps a copy of the YubiKey's HMAC-SHA1 secret here so that backup keys
+		// can be programmed with it.
+		if len(data) != yubiSecretKeyLen {
+			h.keepUndecoded(typ, data, fmt.Errorf("expected %d bytes for YubiKey secret key", yubiSecretKeyLen))
+		} else {
+			h.YubikeySecret = data
+		}
 	case emptyGroupsField:
-		h.emptyGroups = append(h.emptyGroups, string(data))
+		h.EmptyGroups = append(h.EmptyGroups, groupPathSyntax.Parse(string(data)))
+	case masterPasswordChangedAtField:
+		h.MasterPasswordChangedAt, err = parseHeaderTimestamp(data)
 	default:
-		if h.ignoredFields == nil {
-			h.ignoredFields = make(map[byte][]byte, 0)
-		}
-		h.ignoredFields[typ] = data
+		h.unknownFields = append(h.unknownFields, field{typ, data})
 	}
 	return err
 }
 
+// keepUndecoded logs a warning about an informational field that couldn't be decoded, and keeps
+// it with the unknown fields instead of failing to open the database.
+func (h *Header) keepUndecoded(typ byte, data []byte, err error) {
+	log.Printf("warning: header field 0x%02x: %v; keeping its raw value", typ, err)
+	h.unknownFields = append(h.unknownFields, field{typ, data})
+}
+
+// https://github.com/pwsafe/pwsafe/blob/809a171cde0c7d984d81bfc911e5c4378d47cd7b/docs/formatV3.txt#L206
+func parseHeaderTimestamp(data []byte) (time.Time, error) {
+	if len(data) == 4 {
+		return util.ParseTimestamp(data)
+	} else if len(data) == 8 {
+		ts, err := hex.DecodeString(string(data))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("hex.DecodeString: %w", err)
+		}
+		for i, j := 0, len(ts)-1; i < j; i, j = i+1, j-1 {
+			ts[i], ts[j] = ts[j], ts[i]
+		}
+		return util.ParseTimestamp(ts)
+	}
+	return time.Time{}, errors.New("expected 4 or 8 bytes for timestamp")
+}
+
+// parsePreferences parses preferences written as space-separated triples of type, id and value,
+// e.g. `B 24 1 I 11 2 S 3 "luke"`. String values are enclosed by a delimiter character, which is
+// usually a double quote.
+func parsePreferences(s string) ([]Preference, error) {
+	var prefs []Preference
+	for {
+		s = strings.TrimLeft(s, " ")
+		if s == "" {
+			return prefs, nil
+		}
+
+		var p Preference
+		var ok bool
+		var id string
+		p.Type = s[0]
+		id, s, ok = strings.Cut(strings.TrimLeft(s[1:], " "), " ")
+		if !ok {
+			return nil, fmt.Errorf("expected a value for preference %q", id)
+		}
+		n, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, fmt.Errorf("strconv.Atoi: %w", err)
+		}
+		p.Id = n
+
+		s = strings.TrimLeft(s, " ")
+		switch p.Type {
+		case 'B', 'I':
+			p.Value, s, _ = strings.Cut(s, " ")
+		case 'S':
+			if s == "" {
+				return nil, fmt.Errorf("expected a value for preference %d", p.Id)
+			}
+			delim := s[:1]
+			p.Value, s, ok = strings.Cut(s[1:], delim)
+			if !ok {
+				return nil, fmt.Errorf("unterminated value for preference %d", p.Id)
+			}
+		default:
+			return nil, fmt.Errorf("unknown preference type %q", p.Type)
+		}
+		prefs = append(prefs, p)
+	}
+}
+
+func parseLastSavedBy(s string) (string, string, error) {
+	if len(s) < 4 {
+		return "", "", errors.New("expected at least 4 characters for last saved by")
+	}
+	n, err := strconv.ParseUint(s[:4], 16, 16)
+	if err != nil {
+		return "", "", fmt.Errorf("strconv.ParseUint: %w", err)
+	}
+	if int(n) > len(s)-4 {
+		return "", "", errors.New("user name is longer than last saved by")
+	}
+	return s[4 : 4+n], s[4+n:], nil
+}
+
+// parseRecentlyUsedEntries parses a count in 2 hex digits, followed by that many UUIDs in hex.
+func parseRecentlyUsedEntries(s string) ([]uuid.UUID, error) {
+	p := hexFieldParser{s: s}
+	n := p.int(2)
+	var ids []uuid.UUID
+	for i := 0; i < n; i++ {
+		s := p.string(32)
+		if p.err != nil {
+			return nil, p.err
+		}
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("uuid.Parse: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, p.err
+}
+
+// parsePasswordPolicies parses a count in 2 hex digits, followed by that many policies. Each
+// policy is its name, flags, length, minimum counts of each character class and symbols, all
+// preceded by their lengths or as fixed width hex numbers.
+//
+// https://github.com/pwsafe/pwsafe/blob/809a171cde0c7d984d81bfc911e5c4378d47cd7b/docs/formatV3.txt
+func parsePasswordPolicies(s string) ([]PasswordPolicy, error) {
+	p := hexFieldParser{s: s}
+	n := p.int(2)
+	var policies []PasswordPolicy
+	for i := 0; i < n && p.err == nil; i++ {
+		var pp PasswordPolicy
+		pp.Name = p.string(p.int(2))
+		pp.Flags = uint16(p.int(4))
+		pp.Length = p.int(3)
+		pp.MinLowercase = p.int(3)
+		pp.MinUppercase = p.int(3)
+		pp.MinDigits = p.int(3)
+		pp.MinSymbols = p.int(3)
+		pp.Symbols = p.string(p.int(2))
+		policies = append(policies, pp)
+	}
+	return policies, p.err
+}
+
+// hexFieldParser reads the fixed width hex numbers and length-prefixed strings that PasswordSafe
+// packs into some text fields. Lengths count characters, not bytes. After the first error, it
+// returns zero values.
+type hexFieldParser struct {
+	s   string
+	err error
+}
+
+func (p *hexFieldParser) string(n int) string {
+	if p.err != nil {
+		return ""
+	}
+	i := 0
+	for j := 0; j < n; j++ {
+		if i >= len(p.s) {
+			p.err = fmt.Errorf("expected %d characters", n)
+			return ""
+		}
+		_, size := utf8.DecodeRuneInString(p.s[i:])
+		i += size
+	}
+	v := p.s[:i]
+	p.s = p.s[i:]
+	return v
+}
+
+func (p *hexFieldParser) int(n int) int {
+	s := p.string(n)
+	if p.err != nil {
+		return 0
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		p.err = fmt.Errorf("strconv.ParseUint: %w", err)
+		return 0
+	}
+	return int(v)
+}
+
 // https://github.com/pwsafe/pwsafe/blob/809a171cde0c7d984d81bfc911e5c4378d47cd7b/docs/formatV3.txt#L138
 const (
 	versionField                 byte = 0x00
@@ -106,3 +358,5 @@ const (
 	masterPasswordChangedAtField byte = 0x13
 	endOfHeader                  byte = 0xff
 )
+
+const yubiSecretKeyLen = 20
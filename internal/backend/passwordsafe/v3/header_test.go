@@ -0,0 +1,118 @@
+package v3
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseHeader_yubico(t *testing.T) {
+	secret := bytes.Repeat([]byte{0x42}, 20)
+
+	h, err := parseHeader(record{fields: []field{{yubicoField, secret}}})
+
+	assert.Nil(t, err)
+	assert.Equal(t, secret, h.YubikeySecret)
+
+	h, err = parseHeader(record{fields: []field{{yubicoField, secret[:19]}}})
+
+	assert.Nil(t, err)
+	assert.Nil(t, h.YubikeySecret)
+	assert.Equal(t, []field{{yubicoField, secret[:19]}}, h.unknownFields)
+}
+
+func FuzzParseHeader(f *testing.F) {
+	f.Add([]byte{versionField}, []byte{0x0d, 0x03})
+	f.Add([]byte{headerUuidField}, bytes.Repeat([]byte{0x42}, 16))
+	f.Add([]byte{lastSavedAtField}, []byte("6429d8b0"))
+	f.Add([]byte{emptyGroupsField}, []byte(`Group.Sub\.group`))
+	f.Add([]byte{yubicoField}, bytes.Repeat([]byte{0x42}, 20))
+
+	f.Fuzz(func(t *testing.T, types, data []byte) {
+		// Give each field type in types an equal share of data.
+		var r record
+		for i, typ := range types {
+			r.fields = append(r.fields, field{typ, data[i*len(data)/len(types) : (i+1)*len(data)/len(types)]})
+		}
+		_, _ = parseHeader(r)
+	})
+}
+
+func Test_parseHeader_unknownFields(t *testing.T) {
+	fields := []field{
+		{0x7e, []byte("first")},
+		{databaseNameField, []byte("name")},
+		{0x7f, []byte("second")},
+		{0x7e, []byte("repeated")},
+	}
+
+	h, err := parseHeader(record{fields: fields})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "name", h.Name)
+	assert.Equal(t, []field{{0x7e, []byte("first")}, {0x7f, []byte("second")}, {0x7e, []byte("repeated")}}, h.unknownFields)
+}
+
+func Test_parseHeader_undecodableFields(t *testing.T) {
+	fields := []field{
+		{nonDefaultPreferencesField, []byte("B 24")},
+		{lastSavedByField, []byte("00ffluke")},
+		{databaseNameField, []byte("name")},
+		{recentlyUsedEntriesField, []byte("01not-a-uuid")},
+		{namedPasswordPoliciesField, []byte("zz")},
+	}
+
+	h, err := parseHeader(record{fields: fields})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "name", h.Name)
+	assert.Nil(t, h.Preferences)
+	assert.Equal(t, "", h.LastSavedByWhom)
+	assert.Nil(t, h.RecentlyUsedEntries)
+	assert.Nil(t, h.NamedPasswordPolicies)
+	assert.Equal(t, []field{fields[0], fields[1], fields[3], fields[4]}, h.unknownFields)
+}
+
+func Test_parsePreferences(t *testing.T) {
+	testCases := []struct {
+		s         string
+		expected  []Preference
+		expectErr bool
+	}{
+		{"", nil, false},
+		{`B 24 1 I 11 2 S 3 "luke" `, []Preference{{'B', 24, "1"}, {'I', 11, "2"}, {'S', 3, "luke"}}, false},
+		{`S 3 |a "quoted" name|`, []Preference{{'S', 3, `a "quoted" name`}}, false},
+		{`S 3 "unterminated`, nil, true},
+		{`B x 1`, nil, true},
+		{`B 24`, nil, true},
+		{`X 1 1`, nil, true},
+	}
+
+	for _, tc := range testCases {
+		prefs, err := parsePreferences(tc.s)
+		if tc.expectErr {
+			assert.NotNil(t, err, tc.s)
+		} else {
+			assert.Nil(t, err, tc.s)
+			assert.Equal(t, tc.expected, prefs, tc.s)
+		}
+	}
+}
+
+func Test_parseLastSavedBy(t *testing.T) {
+	whom, host, err := parseLastSavedBy("0004lukeOWENS-PC")
+	assert.Nil(t, err)
+	assert.Equal(t, "luke", whom)
+	assert.Equal(t, "OWENS-PC", host)
+
+	_, _, err = parseLastSavedBy("0010luke")
+	assert.NotNil(t, err)
+}
+
+func Test_parsePasswordPolicies_errors(t *testing.T) {
+	for _, s := range []string{"", "01", "0104name", "0104namef000018000000000000", "0104namef00001800000000000002!"} {
+		_, err := parsePasswordPolicies(s)
+		assert.NotNil(t, err, s)
+	}
+}
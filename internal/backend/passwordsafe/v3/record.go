@@ -1,11 +1,12 @@
 package v3
 
 import (
+	"encoding/base32"
 	"encoding/binary"
-	"encoding/hex"
+	"errors"
 	"fmt"
-	"hash"
 	"io"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -15,8 +16,11 @@ import (
 	"notpass-go/pkg/vault"
 )
 
+// record is a header or record as it was read, with its fields in order. end is the field that
+// ended it, which is kept so that records can be written back unchanged.
 type record struct {
 	fields []field
+	end    field
 }
 
 type field struct {
@@ -26,49 +30,142 @@ type field struct {
 
 const chunkSize = twofish.BlockSize
 
-func readRecord(r io.Reader, h hash.Hash, eor byte) (record, error) {
+// PasswordSafe separates group levels with a period. Literal periods are escaped with a backslash.
+var groupPathSyntax = vault.GroupPathSyntax{Separator: '.', Escape: '\\'}
+
+// readRecord reads fields until one of type eor. It returns io.EOF if r is empty, and an error
+// wrapping vault.ErrIntegrity if r ends part way through the record.
+func readRecord(r io.Reader, eor byte) (record, error) {
 	var rec record
 	for {
-		f, err := readField(r, h)
-		if err != nil {
+		f, err := readField(r)
+		if errors.Is(err, io.EOF) && len(rec.fields) > 0 {
+			return record{}, fmt.Errorf("readField: %w (record has no end field)", vault.ErrIntegrity)
+		} else if err != nil {
 			return record{}, fmt.Errorf("readField: %w", err)
 		}
 		if f.typ == eor {
+			rec.end = f
 			return rec, nil
 		}
 		rec.fields = append(rec.fields, f)
 	}
 }
 
-func readField(r io.Reader, h hash.Hash) (field, error) {
+// readField reads a field and the padding that follows it. The field's length comes from
+// decrypted data that may not have been authenticated yet, so its data is read incrementally
+// rather than allocated up front.
+func readField(r io.Reader) (field, error) {
 	lt := make([]byte, 5)
-	_, err := r.Read(lt)
-	if err != nil {
-		return field{}, fmt.Errorf("r.Read: %w", err)
+	_, err := io.ReadFull(r, lt)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return field{}, fmt.Errorf("io.ReadFull: %w (incomplete field header)", vault.ErrIntegrity)
+	} else if err != nil {
+		return field{}, fmt.Errorf("io.ReadFull: %w", err)
 	}
 
-	recLen := int(binary.LittleEndian.Uint32(lt))
+	recLen := int64(binary.LittleEndian.Uint32(lt))
 	f := field{
 		typ: lt[4],
 	}
 
-	f.data = make([]byte, recLen)
-	_, err = r.Read(f.data)
+	f.data, err = io.ReadAll(io.LimitReader(r, recLen))
 	if err != nil {
-		return field{}, fmt.Errorf("r.Read: %w", err)
+		return field{}, fmt.Errorf("io.ReadAll: %w", err)
+	}
+	if int64(len(f.data)) != recLen {
+		return field{}, fmt.Errorf("%w (expected %d bytes of field data, found %d)", vault.ErrIntegrity, recLen, len(f.data))
 	}
 
-	padLen := chunkSize - ((len(lt) + recLen) % chunkSize)
+	padLen := chunkSize - ((int64(len(lt)) + recLen) % chunkSize)
 	if padLen != chunkSize {
 		padding := make([]byte, padLen)
-		_, err = r.Read(padding)
+		_, err = io.ReadFull(r, padding)
+		if err != nil {
+			return field{}, fmt.Errorf("io.ReadFull: %w (incomplete field padding)", vault.ErrIntegrity)
+		}
 	}
 
-	h.Write(f.data)
-
 	return f, nil
 }
 
+func asGroup(b []byte) (vault.Value, error) {
+	return vault.String(groupPathSyntax.Parse(string(b)).String()), nil
+}
+
+func asDays(b []byte) (vault.Value, error) {
+	if len(b) != 4 {
+		return nil, fmt.Errorf("expected 4 bytes for interval")
+	}
+	days := binary.LittleEndian.Uint32(b)
+	return vault.Duration(time.Duration(days) * 24 * time.Hour), nil
+}
+
+func asSeconds(b []byte) (vault.Value, error) {
+	if len(b) != 1 {
+		return nil, fmt.Errorf("expected 1 byte for time step")
+	}
+	return vault.Duration(time.Duration(b[0]) * time.Second), nil
+}
+
+func asInt(b []byte) (vault.Value, error) {
+	if len(b) != 1 {
+		return nil, fmt.Errorf("expected 1 byte for number")
+	}
+	return vault.Int(b[0]), nil
+}
+
+// The low two bits of the TOTP configuration select the HMAC algorithm. PasswordSafe only defines
+// HMAC-SHA1 so far.
+func asTotpAlgorithm(b []byte) (vault.Value, error) {
+	if len(b) != 1 {
+		return nil, fmt.Errorf("expected 1 byte for TOTP configuration")
+	}
+	if b[0]&0x03 != 0 {
+		return nil, fmt.Errorf("unsupported TOTP algorithm: %d", b[0]&0x03)
+	}
+	return vault.String("SHA1"), nil
+}
+
+// PasswordSafe's double-click actions, in the order of their values.
+var doubleClickActions = []string{
+	"copyPassword",
+	"viewEdit",
+	"autotype",
+	"browse",
+	"copyNotes",
+	"copyUsername",
+	"copyPasswordMinimize",
+	"browsePlus",
+	"run",
+	"sendEmail",
+}
+
+// doubleClickDefault means the entry uses the application's default double-click action.
+const doubleClickDefault = 0xff
+
+func asDoubleClickAction(b []byte) (vault.Value, error) {
+	if len(b) != 2 {
+		return nil, fmt.Errorf("expected 2 bytes for double-click action")
+	}
+	a := binary.LittleEndian.Uint16(b)
+	if a == doubleClickDefault {
+		return vault.String("default"), nil
+	} else if int(a) >= len(doubleClickActions) {
+		// An action added by a later version of PasswordSafe.
+		return vault.Int(a), nil
+	}
+	return vault.String(doubleClickActions[a]), nil
+}
+
+// PasswordSafe stores flags as a single byte, which is non-zero if set.
+func asBool(b []byte) (vault.Value, error) {
+	if len(b) != 1 {
+		return nil, fmt.Errorf("expected 1 byte for flag")
+	}
+	return vault.Bool(b[0] != 0), nil
+}
+
 func asTimestamp(b []byte) (vault.Value, error) {
 	t, err := util.ParseTimestamp(b)
 	return vault.Timestamp(t), err
@@ -86,10 +183,37 @@ func asSensitiveString(b []byte) (vault.Value, error) {
 	return sensitive.String(b), nil
 }
 
+func asBase32SensitiveString(b []byte) (vault.Value, error) {
+	return sensitive.String(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)), nil
+}
+
 func asString(b []byte) (vault.Value, error) {
 	return vault.String(b), nil
 }
 
-func asHexString(b []byte) (vault.Value, error) {
-	return vault.String(hex.EncodeToString(b)), nil
+func asBytes(b []byte) (vault.Value, error) {
+	return vault.Bytes(b), nil
+}
+
+// asPasswordHistory parses a password history: a flag for whether it's kept, the maximum number
+// of passwords to keep and the number kept, in 1, 2 and 2 hex digits, followed by each password.
+// Each password is preceded by the time it was replaced, in 8 hex digits, and its length, in 4.
+// The passwords are returned oldest first, each as a list of the time and the password.
+//
+// https://github.com/pwsafe/pwsafe/blob/809a171cde0c7d984d81bfc911e5c4378d47cd7b/docs/formatV3.txt
+func asPasswordHistory(b []byte) (vault.Value, error) {
+	p := hexFieldParser{s: string(b)}
+	p.int(1)
+	p.int(2)
+	n := p.int(2)
+	history := vault.SecretList{}
+	for i := 0; i < n && p.err == nil; i++ {
+		t := time.Unix(int64(p.int(8)), 0)
+		password := p.string(p.int(4))
+		history = append(history, vault.List{vault.Timestamp(t), sensitive.String(password)})
+	}
+	if p.err != nil {
+		return nil, fmt.Errorf("password history: %w", p.err)
+	}
+	return history, nil
 }
@@ -0,0 +1,82 @@
+package v3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/pkg/vault"
+)
+
+// encodeField encodes a field as it appears in a decrypted database, padded to a whole number of
+// blocks.
+func encodeField(typ byte, data []byte) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, uint32(len(data)))
+	b = append(b, typ)
+	b = append(b, data...)
+	if pad := len(b) % chunkSize; pad != 0 {
+		b = append(b, make([]byte, chunkSize-pad)...)
+	}
+	return b
+}
+
+func Test_readRecord(t *testing.T) {
+	data := append(encodeField(0x03, []byte("a name that spans more than one block")), encodeField(0x04, []byte("user"))...)
+	data = append(data, encodeField(endOfRecord, nil)...)
+
+	rec, err := readRecord(bytes.NewReader(data), endOfRecord)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []field{{0x03, []byte("a name that spans more than one block")}, {0x04, []byte("user")}}, rec.fields)
+}
+
+func Test_readRecord_errors(t *testing.T) {
+	hugeField := encodeField(0x03, []byte("short"))
+	binary.LittleEndian.PutUint32(hugeField, 0xffffffff)
+
+	testCases := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{"empty", nil, io.EOF},
+		{"no end field", encodeField(0x03, []byte("name")), vault.ErrIntegrity},
+		{"incomplete field header", []byte{0x01, 0x00}, vault.ErrIntegrity},
+		{"length beyond data", hugeField, vault.ErrIntegrity},
+		{"missing padding", encodeField(0x03, []byte("name"))[:12], vault.ErrIntegrity},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := readRecord(bytes.NewReader(tc.data), endOfRecord)
+			assert.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func FuzzReadRecord(f *testing.F) {
+	f.Add(append(encodeField(0x03, []byte("name")), encodeField(endOfRecord, nil)...))
+	f.Add(encodeField(0x05, bytes.Repeat([]byte("note"), 20)))
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0x03})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		rec, err := readRecord(bytes.NewReader(data), endOfRecord)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, vault.ErrIntegrity) {
+				t.Errorf("unexpected error: %v", err)
+			}
+			return
+		}
+		size := 0
+		for _, f := range rec.fields {
+			size += len(f.data)
+		}
+		if size > len(data) {
+			t.Errorf("record has %d bytes of field data, more than the %d bytes read", size, len(data))
+		}
+	})
+}
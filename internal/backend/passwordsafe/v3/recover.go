@@ -0,0 +1,166 @@
+package v3
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"notpass-go/internal/backend/passwordsafe/crypto/twofish"
+	"notpass-go/pkg/vault"
+)
+
+// Recovery is what Recover could salvage from a damaged database.
+//
+// Authenticated is true if the database's HMAC matched, in which case nothing was lost. Otherwise,
+// the salvaged entries may include undetected damage and should be checked before they are relied
+// on. Lost lists the parts of the file that could not be read, as offsets from the start of the
+// file.
+type Recovery struct {
+	Header        Header
+	Entries       []vault.Entry
+	Lost          []ByteRange
+	Authenticated bool
+}
+
+// ByteRange is the half-open range of bytes [Start, End) in a file.
+type ByteRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Recover salvages as many entries as possible from a database that can't be opened with OpenDb
+// because it is corrupt or truncated. The password must still be correct, so the start of the file
+// up to the ciphertext must be intact.
+//
+// Since CBC mode limits the effect of a damaged block to itself and the block after it, records
+// away from the damage decrypt correctly. Recover reads records until one is malformed, then skips
+// a block at a time until it finds the start of a plausible record.
+func Recover(dbPath, password string) (*Recovery, error) {
+	data, err := os.ReadFile(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	dbf, lostTail, err := salvageDbFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("salvageDbFile: %w", err)
+	}
+
+	plaintext, hmacKey, err := decryptCiphertext(dbf, password)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &Recovery{
+		Authenticated: len(lostTail) == 0 && authenticate(plaintext, hmacKey, dbf.Hmac()) == nil,
+	}
+
+	var lost []ByteRange
+	pos := 0
+	hdr, n, err := readRecordAt(plaintext, pos, endOfHeader)
+	if err == nil {
+		rec.Header, _ = parseHeader(hdr)
+		pos += n
+	}
+
+	for pos < len(plaintext) {
+		r, n, err := readRecordAt(plaintext, pos, endOfRecord)
+		if err == nil {
+			if e, ok := plausibleEntry(r); ok {
+				rec.Entries = append(rec.Entries, e)
+				pos += n
+				continue
+			}
+		}
+
+		start := pos
+		for pos += twofish.BlockSize; pos < len(plaintext); pos += twofish.BlockSize {
+			r, _, err := readRecordAt(plaintext, pos, endOfRecord)
+			if _, ok := plausibleEntry(r); err == nil && ok {
+				break
+			}
+		}
+		lost = append(lost, ByteRange{prefixLen + start, prefixLen + min(pos, len(plaintext))})
+	}
+
+	rec.Lost = mergeByteRanges(append(lost, lostTail...))
+	return rec, nil
+}
+
+// salvageDbFile reads as much of a database file as possible. If the file is truncated, the
+// ciphertext is cut back to a whole number of blocks, and the rest of the file is reported as lost.
+func salvageDbFile(data []byte) (*dbFile, []ByteRange, error) {
+	dbf, err := decodeDbFile(bytes.NewReader(data))
+	if err == nil {
+		return dbf, nil, nil
+	} else if !errors.Is(err, vault.ErrTruncated) {
+		return nil, nil, err
+	}
+
+	dbf = &dbFile{}
+	err = dbf.readPrefix(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	end := prefixLen
+	for ; end+twofish.BlockSize <= len(data); end += twofish.BlockSize {
+		if bytes.Equal(data[end:end+twofish.BlockSize], []byte(eof)) {
+			break
+		}
+	}
+	dbf.ciphertext = data[prefixLen:end]
+
+	var lost []ByteRange
+	if end+twofish.BlockSize > len(data) && end < len(data) {
+		lost = append(lost, ByteRange{end, len(data)})
+	}
+	return dbf, lost, nil
+}
+
+// readRecordAt reads a record that starts at offset pos in data, and returns the number of bytes
+// it took up.
+func readRecordAt(data []byte, pos int, eor byte) (record, int, error) {
+	r := bytes.NewReader(data[pos:])
+	rec, err := readRecord(r, eor)
+	return rec, len(data) - pos - r.Len(), err
+}
+
+// plausibleEntry returns the entry for a record if it looks like it was written by PasswordSafe:
+// it has a valid UUID, and every field is one PasswordSafe defines and is valid.
+//
+// Unlike parseEntries, which keeps fields it can't decode as raw bytes, this rejects the record if
+// any field doesn't decode, since a block of garbage is likely to contain some.
+func plausibleEntry(r record) (vault.Entry, bool) {
+	if len(r.fields) == 0 {
+		return vault.Entry{}, false
+	}
+	for _, f := range r.fields {
+		x, ok := fieldMap[f.typ]
+		if !ok {
+			return vault.Entry{}, false
+		}
+		if _, err := x.parse(f.data); err != nil {
+			return vault.Entry{}, false
+		}
+	}
+	entries, err := parseEntries([]record{r})
+	if err != nil || len(entries) != 1 || entries[0].Id() == "" {
+		return vault.Entry{}, false
+	}
+	return entries[0], true
+}
+
+// mergeByteRanges merges adjacent ranges, which must be in order.
+func mergeByteRanges(ranges []ByteRange) []ByteRange {
+	var merged []ByteRange
+	for _, r := range ranges {
+		if len(merged) > 0 && merged[len(merged)-1].End >= r.Start {
+			merged[len(merged)-1].End = max(merged[len(merged)-1].End, r.End)
+		} else {
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,114 @@
+package v3
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/twofish"
+
+	"notpass-go/internal/backend/passwordsafe/crypto"
+	twofishecb "notpass-go/internal/backend/passwordsafe/crypto/twofish"
+	"notpass-go/pkg/vault"
+)
+
+func TestRecover(t *testing.T) {
+	data, err := os.ReadFile(testDb)
+	assert.Nil(t, err)
+	truncated := filepath.Join(t.TempDir(), "truncated.psafe3")
+	assert.Nil(t, os.WriteFile(truncated, data[:len(data)-100], 0600))
+	// A record whose fields are well formed, but one of which doesn't decode, as is likely in a
+	// block of garbage. It must not be salvaged as an entry.
+	undecodable := rewritePlaintext(t, func(plaintext []byte) {
+		pos, records := 0, 0
+		for {
+			n := int(binary.LittleEndian.Uint32(plaintext[pos:]))
+			typ := &plaintext[pos+4]
+			if records > 0 && *typ == 0x07 {
+				*typ = 0x15
+				plaintext[pos+5] ^= 0xff
+				return
+			} else if *typ == 0xff {
+				records++
+			}
+			pos += (5 + n + 15) / 16 * 16
+		}
+	})
+
+	testCases := []struct {
+		dbFile        string
+		entries       int
+		lost          []ByteRange
+		authenticated bool
+	}{
+		{testDb, 9, nil, true},
+		{"testdata/test-tampered-hmac.psafe3", 9, nil, false},
+		{"testdata/test-tampered-ciphertext.psafe3", 8, []ByteRange{{1736, 2008}}, false},
+		{"testdata/test-truncated-record.psafe3", 8, []ByteRange{{3080, 3576}}, false},
+		{truncated, 8, []ByteRange{{3080, 3572}}, false},
+		{undecodable, 8, []ByteRange{{744, 904}}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(filepath.Base(tc.dbFile), func(t *testing.T) {
+			rec, err := Recover(tc.dbFile, password)
+
+			assert.Nil(t, err)
+			assert.Equal(t, "Test database", rec.Header.Name)
+			assert.Len(t, rec.Entries, tc.entries)
+			assert.Equal(t, tc.lost, rec.Lost)
+			assert.Equal(t, tc.authenticated, rec.Authenticated)
+		})
+	}
+}
+
+// rewritePlaintext writes a copy of the test database with its decrypted header and records changed
+// by edit, and returns its path. The HMAC isn't updated.
+func rewritePlaintext(t *testing.T, edit func(plaintext []byte)) string {
+	data, err := os.ReadFile(testDb)
+	assert.Nil(t, err)
+	dbf, err := decodeDbFile(bytes.NewReader(data))
+	assert.Nil(t, err)
+	masterKey, err := crypto.DeriveKeySha256([]byte(password), dbf.Salt(), dbf.Iterations(), dbf.MasterKeyHash())
+	assert.Nil(t, err)
+	encryptionKey, err := twofishecb.DecryptECB(masterKey, dbf.EncryptionKey())
+	assert.Nil(t, err)
+	plaintext, _, err := decryptCiphertext(dbf, password)
+	assert.Nil(t, err)
+
+	edit(plaintext)
+
+	c, err := twofish.NewCipher(encryptionKey)
+	assert.Nil(t, err)
+	cipher.NewCBCEncrypter(c, dbf.Iv()).CryptBlocks(data[prefixLen:], plaintext)
+	path := filepath.Join(t.TempDir(), "rewritten.psafe3")
+	assert.Nil(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+func TestRecover_errors(t *testing.T) {
+	testCases := []struct {
+		dbFile   string
+		password string
+		expected error
+	}{
+		{"testdata/nonexistent", password, os.ErrNotExist},
+		{"testdata/test-bad-tag.psafe3", password, vault.ErrUnsupportedFormat},
+		{testDb, "12345", vault.ErrIncorrectPassword},
+	}
+
+	for _, tc := range testCases {
+		rec, err := Recover(tc.dbFile, tc.password)
+		assert.ErrorIs(t, err, tc.expected, tc.dbFile)
+		assert.Nil(t, rec)
+	}
+}
+
+func Test_mergeByteRanges(t *testing.T) {
+	assert.Nil(t, mergeByteRanges(nil))
+	assert.Equal(t, []ByteRange{{0, 32}, {48, 64}}, mergeByteRanges([]ByteRange{{0, 16}, {16, 32}, {48, 64}}))
+}
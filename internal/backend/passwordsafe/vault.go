@@ -8,10 +8,17 @@ import (
 	"notpass-go/pkg/vault"
 )
 
+// Header holds a safe's properties, such as who last saved it.
+type Header = v3.Header
+
 type Vault interface {
 	vault.ReadableVault
 	vault.SearchableVault
 	Name() string
+	EmptyGroups() []vault.GroupPath
+	YubikeySecret() ([]byte, bool)
+	Iterations() uint
+	Header() Header
 }
 
 func OpenVault(dbFile, password string) (Vault, error) {
@@ -29,6 +36,16 @@ func OpenVault(dbFile, password string) (Vault, error) {
 		return v, nil
 
 	default:
-		return nil, fmt.Errorf("unsupported PasswordSafe database format (only v3 databases are supported)")
+		return nil, fmt.Errorf("%w (only PasswordSafe v3 databases are supported)", vault.ErrUnsupportedFormat)
+	}
+}
+
+// RecoverVault salvages what it can from a damaged safe that OpenVault rejects. Only v3 safes can
+// be recovered.
+func RecoverVault(dbFile, password string) (*v3.Recovery, error) {
+	r, err := v3.Recover(dbFile, password)
+	if err != nil {
+		return nil, fmt.Errorf("v3.Recover: %w", err)
 	}
+	return r, nil
 }
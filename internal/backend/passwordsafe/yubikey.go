@@ -1,66 +1,79 @@
 package passwordsafe
 
 import (
-	"encoding/binary"
-	"encoding/hex"
+	"context"
 	"fmt"
 	"log"
-	"unicode/utf16"
 
+	"notpass-go/internal/chalresp"
 	"notpass-go/internal/yubikey"
 )
 
-func PasswordFromYubikey(userPassword string) (string, error) {
-	challenge := encodeChallenge(userPassword)
+// YubikeyOptions selects the YubiKey used to open a safe.
+//
+// Slot is the challenge-response slot (1 or 2) that holds the safe's secret. If it is zero, slot 2
+// is used, since that is where PasswordSafe expects the secret by default. If Key is set, it is
+// used instead of a connected YubiKey, e.g. an emulator loaded with yubikey.OpenEmulatorFile.
+// Otherwise, if Serial is empty, the first YubiKey found is used.
+type YubikeyOptions struct {
+	Slot   int
+	Serial string
+	Key    yubikey.YubiKey
+}
 
-	yk, err := yubikey.Open()
-	if err != nil {
-		return "", fmt.Errorf("yubikey.Open: %w", err)
+// PasswordFromYubikey computes a safe's password by sending userPassword as a challenge to a
+// YubiKey. It returns early with ctx's error if ctx is cancelled while waiting for the YubiKey.
+func PasswordFromYubikey(ctx context.Context, userPassword string, opts YubikeyOptions) (string, error) {
+	slot := opts.Slot
+	if slot == 0 {
+		slot = defaultYubikeySlot
+	}
+	if slot != 1 && slot != 2 {
+		return "", fmt.Errorf("invalid YubiKey slot: expected 1 or 2")
 	}
-	defer func() {
-		err := yk.Close()
-		if err != nil {
-			log.Printf("failed to close YubiKey: %v", err)
+
+	yk := opts.Key
+	if yk == nil {
+		var err error
+		if opts.Serial != "" {
+			yk, err = yubikey.OpenBySerialNumber(opts.Serial)
+			if err != nil {
+				return "", fmt.Errorf("yubikey.OpenBySerialNumber: %w", err)
+			}
+		} else {
+			yk, err = yubikey.Open()
+			if err != nil {
+				return "", fmt.Errorf("yubikey.Open: %w", err)
+			}
 		}
-	}()
+		defer func() {
+			err := yk.Close()
+			if err != nil {
+				log.Printf("failed to close YubiKey: %v", err)
+			}
+		}()
+	}
 
-	h, err := yk.ChallengeResponseHmacSha1(2, challenge)
+	key, err := chalresp.Key(ctx, chalresp.Slot(yk, slot), chalresp.PasswordSafe{Password: userPassword})
 	if err != nil {
-		return "", fmt.Errorf("yubikey.ChallengeResponseHmacSha1: %w", err)
+		return "", fmt.Errorf("chalresp.Key: %w", err)
 	}
 
-	return hex.EncodeToString(h), nil
+	return string(key), nil
 }
 
 func PasswordFromEmulatedYubikey(credential []byte, userPassword string) (string, error) {
-	challenge := encodeChallenge(userPassword)
-
 	yk, err := yubikey.NewEmulator(map[int][]byte{2: credential})
 	if err != nil {
 		return "", fmt.Errorf("yubikey.NewEmulator: %w", err)
 	}
 
-	h, err := yk.ChallengeResponseHmacSha1(2, challenge)
+	key, err := chalresp.Key(context.Background(), chalresp.Slot(yk, 2), chalresp.PasswordSafe{Password: userPassword})
 	if err != nil {
-		return "", fmt.Errorf("yubikey.ChallengeResponseHmacSha1: %w", err)
+		return "", fmt.Errorf("chalresp.Key: %w", err)
 	}
 
-	return hex.EncodeToString(h), nil
+	return string(key), nil
 }
 
-func encodeChallenge(challenge string) []byte {
-	b := toUtf16leBytes(challenge)
-	if len(b) > yubikey.MaxChallengeLen {
-		return b[:yubikey.MaxChallengeLen]
-	}
-	return b
-}
-
-func toUtf16leBytes(s string) []byte {
-	codes := utf16.Encode([]rune(s))
-	b := make([]byte, len(codes)*2)
-	for i, r := range codes {
-		binary.LittleEndian.PutUint16(b[i*2:], r)
-	}
-	return b
-}
+const defaultYubikeySlot = 2
@@ -0,0 +1,130 @@
+package chalresp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"unicode/utf16"
+
+	"notpass-go/internal/yubikey"
+)
+
+// Responder answers HMAC-SHA1 challenges.
+type Responder interface {
+	ChallengeResponse(ctx context.Context, challenge []byte) ([]byte, error)
+}
+
+// KeyProvider is a vault format's scheme for deriving key material from a challenge-response
+// token. Any Responder can be used with any KeyProvider, so the same YubiKey slot (or a backup of
+// its secret loaded into the emulator) can unlock vaults of different formats.
+type KeyProvider interface {
+	// Challenge returns the challenge to send to the token.
+	Challenge() ([]byte, error)
+	// Key turns the token's response into key material.
+	Key(response []byte) ([]byte, error)
+}
+
+// Key sends p's challenge to r and returns the key material p derives from the response.
+func Key(ctx context.Context, r Responder, p KeyProvider) ([]byte, error) {
+	challenge, err := p.Challenge()
+	if err != nil {
+		return nil, fmt.Errorf("p.Challenge: %w", err)
+	}
+	if len(challenge) > yubikey.MaxChallengeLen {
+		return nil, fmt.Errorf("expected challenge to be no more than %d bytes", yubikey.MaxChallengeLen)
+	}
+
+	response, err := r.ChallengeResponse(ctx, challenge)
+	if err != nil {
+		return nil, fmt.Errorf("r.ChallengeResponse: %w", err)
+	}
+
+	return p.Key(response)
+}
+
+// Slot returns a Responder that uses a YubiKey's HMAC-SHA1 challenge-response slot.
+func Slot(yk yubikey.YubiKey, slot int) Responder {
+	return slotResponder{yk, slot}
+}
+
+type slotResponder struct {
+	yk   yubikey.YubiKey
+	slot int
+}
+
+func (s slotResponder) ChallengeResponse(ctx context.Context, challenge []byte) ([]byte, error) {
+	return s.yk.ChallengeResponseHmacSha1(ctx, s.slot, challenge)
+}
+
+// PasswordSafe is PasswordSafe's scheme. The challenge is the user's password encoded as
+// UTF-16LE and truncated to 64 bytes, and the hex-encoded response is used as the safe's
+// passphrase.
+type PasswordSafe struct {
+	Password string
+}
+
+func (p PasswordSafe) Challenge() ([]byte, error) {
+	codes := utf16.Encode([]rune(p.Password))
+	b := make([]byte, len(codes)*2)
+	for i, r := range codes {
+		binary.LittleEndian.PutUint16(b[i*2:], r)
+	}
+	if len(b) > yubikey.MaxChallengeLen {
+		return b[:yubikey.MaxChallengeLen], nil
+	}
+	return b, nil
+}
+
+func (p PasswordSafe) Key(response []byte) ([]byte, error) {
+	return []byte(hex.EncodeToString(response)), nil
+}
+
+// KeePassXC is KeePassXC's scheme for KDBX databases. The challenge is the database's master
+// seed, padded to 64 bytes PKCS#7-style, and the key is the SHA-256 hash of the response.
+//
+// KeePassXC computes the final database key as SHA-256(master seed || key || transformed key),
+// where the transformed key comes from the password and key file.
+type KeePassXC struct {
+	MasterSeed []byte
+}
+
+func (k KeePassXC) Challenge() ([]byte, error) {
+	if len(k.MasterSeed) == 0 || len(k.MasterSeed) >= yubikey.MaxChallengeLen {
+		return nil, fmt.Errorf("expected master seed to be 1 to %d bytes", yubikey.MaxChallengeLen-1)
+	}
+	n := yubikey.MaxChallengeLen - len(k.MasterSeed)
+	b := append([]byte{}, k.MasterSeed...)
+	for i := 0; i < n; i++ {
+		b = append(b, byte(n))
+	}
+	return b, nil
+}
+
+func (k KeePassXC) Key(response []byte) ([]byte, error) {
+	h := sha256.Sum256(response)
+	return h[:], nil
+}
+
+// Raw sends Data as the challenge and mixes the response with Secret, for formats that use a
+// challenge-response token as an additional key. The key is SHA-256(Secret || response), or the
+// response itself if Secret is empty.
+type Raw struct {
+	Data   []byte
+	Secret []byte
+}
+
+func (r Raw) Challenge() ([]byte, error) {
+	return r.Data, nil
+}
+
+func (r Raw) Key(response []byte) ([]byte, error) {
+	if len(r.Secret) == 0 {
+		return response, nil
+	}
+	h := sha256.New()
+	h.Write(r.Secret)
+	h.Write(response)
+	return h.Sum(nil), nil
+}
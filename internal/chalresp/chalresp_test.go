@@ -0,0 +1,88 @@
+package chalresp
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/internal/testutil"
+	"notpass-go/internal/yubikey"
+)
+
+var secret = testutil.UnHex("601598b189be7abaf383e5cb15f0429caf20da6a")
+
+func hmacSha1(challenge []byte) []byte {
+	h := hmac.New(sha1.New, secret)
+	h.Write(challenge)
+	return h.Sum(nil)
+}
+
+func TestKey_passwordSafe(t *testing.T) {
+	yk, _ := yubikey.NewEmulator(map[int][]byte{2: secret})
+
+	// The YubiKey ignores trailing padding, which for a UTF-16LE challenge includes the last null
+	// byte, so hmacInput is what the HMAC is actually computed over.
+	testCases := []struct {
+		password  string
+		challenge []byte
+		hmacInput []byte
+	}{
+		{"hunter2", []byte("h\x00u\x00n\x00t\x00e\x00r\x002\x00"), []byte("h\x00u\x00n\x00t\x00e\x00r\x002")},
+		{"ßĕ", []byte{0xdf, 0x00, 0x15, 0x01}, []byte{0xdf, 0x00, 0x15, 0x01}},
+		{strings.Repeat("a", 40), bytes.Repeat([]byte("a\x00"), 32), bytes.Repeat([]byte("a\x00"), 32)[:63]},
+	}
+	for _, tc := range testCases {
+		p := PasswordSafe{Password: tc.password}
+		challenge, err := p.Challenge()
+		assert.Nil(t, err)
+		assert.Equal(t, tc.challenge, challenge)
+
+		key, err := Key(context.Background(), Slot(yk, 2), p)
+		assert.Nil(t, err)
+		assert.Equal(t, hex.EncodeToString(hmacSha1(tc.hmacInput)), string(key))
+	}
+}
+
+func TestKey_keePassXC(t *testing.T) {
+	yk, _ := yubikey.NewEmulator(map[int][]byte{2: secret})
+	seed := testutil.UnHex("3fc5d4b9a1e07e0bb6c6dbf5e0e4e8b8b5d0f2e69e0b4f6bd9b7d18fd4bb1c01")
+	k := KeePassXC{MasterSeed: seed}
+
+	challenge, err := k.Challenge()
+	assert.Nil(t, err)
+	assert.Equal(t, append(seed, bytes.Repeat([]byte{32}, 32)...), challenge)
+
+	key, err := Key(context.Background(), Slot(yk, 2), k)
+	assert.Nil(t, err)
+	// The YubiKey strips the padding before computing the HMAC.
+	expected := sha256.Sum256(hmacSha1(seed))
+	assert.Equal(t, expected[:], key)
+
+	_, err = Key(context.Background(), Slot(yk, 2), KeePassXC{MasterSeed: make([]byte, 64)})
+	assert.NotNil(t, err)
+	_, err = Key(context.Background(), Slot(yk, 2), KeePassXC{})
+	assert.NotNil(t, err)
+}
+
+func TestKey_raw(t *testing.T) {
+	yk, _ := yubikey.NewEmulator(map[int][]byte{2: secret})
+
+	key, err := Key(context.Background(), Slot(yk, 2), Raw{Data: []byte("challenge")})
+	assert.Nil(t, err)
+	assert.Equal(t, hmacSha1([]byte("challenge")), key)
+
+	key, err = Key(context.Background(), Slot(yk, 2), Raw{Data: []byte("challenge"), Secret: []byte("hunter2")})
+	assert.Nil(t, err)
+	expected := sha256.Sum256(append([]byte("hunter2"), hmacSha1([]byte("challenge"))...))
+	assert.Equal(t, expected[:], key)
+
+	_, err = Key(context.Background(), Slot(yk, 2), Raw{Data: make([]byte, 65)})
+	assert.NotNil(t, err)
+}
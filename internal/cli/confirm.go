@@ -0,0 +1,20 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"notpass-go/internal/io"
+)
+
+// Confirm asks the user a yes/no question, defaulting to no. The question goes to standard error.
+func Confirm(prompt string) (bool, error) {
+	_, _ = fmt.Fprintf(os.Stderr, "%s [y/N]: ", prompt)
+	resp, err := io.ReadLine()
+	if err != nil {
+		return false, err
+	}
+	resp = strings.ToLower(strings.TrimSpace(resp))
+	return resp == "y" || resp == "yes", nil
+}
@@ -1,11 +1,11 @@
 package cli
 
 import (
-	"bufio"
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
+
+	"notpass-go/internal/io"
 )
 
 func NumberedMenu[T any](choices []T, format func(int, T) string, defaultChoice, abort string) (int, bool, error) {
@@ -13,10 +13,9 @@ func NumberedMenu[T any](choices []T, format func(int, T) string, defaultChoice,
 		fmt.Println(format(i+1, e))
 	}
 	fmt.Println()
-	r := bufio.NewReader(os.Stdin)
 	for {
 		fmt.Printf("Selection [%s]: ", defaultChoice)
-		resp, err := r.ReadString('\n')
+		resp, err := io.ReadLine()
 		if err != nil {
 			return 0, false, err
 		}
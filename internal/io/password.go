@@ -7,38 +7,70 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
 
 	"golang.org/x/term"
 )
 
+// stdin buffers standard input for everything that reads lines from it. A bufio.Reader reads
+// ahead, so with a reader per prompt the first one would swallow the lines piped in for the
+// prompts after it. It's replaced if os.Stdin is, as tests do.
+var stdin struct {
+	sync.Mutex
+	file   *os.File
+	reader *bufio.Reader
+}
+
+// Stdin returns the buffered reader for standard input that is shared by the whole process.
+func Stdin() *bufio.Reader {
+	stdin.Lock()
+	defer stdin.Unlock()
+	if stdin.file != os.Stdin {
+		stdin.file = os.Stdin
+		stdin.reader = bufio.NewReader(os.Stdin)
+	}
+	return stdin.reader
+}
+
+// ReadLine reads a line from standard input, without its "\n" or "\r\n". The last line doesn't
+// need a line ending. If the input has ended, ReadLine returns io.EOF.
+func ReadLine() (string, error) {
+	line, err := Stdin().ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+// ReadPassword reads a password from the terminal without echoing it, or a line from standard
+// input if it isn't a terminal. Prompts go to standard error, so that they don't mix with the
+// results a command writes to standard output.
 func ReadPassword(prompt string) ([]byte, error) {
-	var password []byte
-	var err error
 	if term.IsTerminal(int(os.Stdin.Fd())) {
-		fmt.Print(prompt)
-		password, err = term.ReadPassword(int(os.Stdin.Fd()))
+		_, _ = fmt.Fprint(os.Stderr, prompt)
+		password, err := term.ReadPassword(int(os.Stdin.Fd()))
 		if err != nil {
 			return nil, fmt.Errorf("term.ReadPassword: %w", err)
 		}
-		fmt.Println()
-	} else {
-		r := bufio.NewReader(os.Stdin)
-		password, err = r.ReadBytes(byte('\n'))
-		if err != nil && !errors.Is(err, io.EOF) {
-			return nil, fmt.Errorf("r.ReadBytes: %w", err)
-		}
+		_, _ = fmt.Fprintln(os.Stderr)
+		return password, nil
+	}
+
+	password, err := ReadLine()
+	if err != nil {
+		return nil, fmt.Errorf("io.ReadLine: %w", err)
 	}
-	return password, nil
+	return []byte(password), nil
 }
 
 func ReadOtp(prompt string) (string, error) {
 	if prompt != "" {
-		fmt.Printf(prompt)
+		_, _ = fmt.Fprint(os.Stderr, prompt)
 	}
-	r := bufio.NewReader(os.Stdin)
-	otp, err := r.ReadString(byte('\n'))
-	if err != nil && !errors.Is(err, io.EOF) {
-		return "", fmt.Errorf("r.ReadString: %w", err)
+	otp, err := ReadLine()
+	if err != nil {
+		return "", fmt.Errorf("io.ReadLine: %w", err)
 	}
 	return strings.TrimSpace(otp), nil
 }
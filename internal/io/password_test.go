@@ -0,0 +1,30 @@
+package io
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadPassword_piped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdin")
+	assert.Nil(t, os.WriteFile(path, []byte("hunter2\r\nbackup passphrase\nlast"), 0600))
+	f, err := os.Open(path)
+	assert.Nil(t, err)
+	defer func() { _ = f.Close() }()
+	oldStdin := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = oldStdin }()
+
+	for _, expected := range []string{"hunter2", "backup passphrase", "last"} {
+		p, err := ReadPassword("Password: ")
+		assert.Nil(t, err)
+		assert.Equal(t, expected, string(p))
+	}
+
+	_, err = ReadPassword("Password: ")
+	assert.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,96 @@
+package yubikey
+
+import (
+	"fmt"
+	"io"
+)
+
+// SmartCard exchanges ISO 7816 APDUs with a smart card, such as the CCID interface of a YubiKey.
+//
+// Transmit sends a command APDU and returns the response APDU, including the status word.
+type SmartCard interface {
+	io.Closer
+	Transmit(command []byte) ([]byte, error)
+}
+
+// StatusError is returned when a smart card responds to a command with a status word other than
+// success.
+type StatusError struct {
+	SW uint16
+}
+
+func (e StatusError) Error() string {
+	switch e.SW {
+	case swSecurityStatusNotSatisfied:
+		return "smart card returned status 6982 (authentication required)"
+	case swNoSuchObject:
+		return "smart card returned status 6984 (no such object)"
+	case swWrongData:
+		return "smart card returned status 6a80 (wrong data)"
+	case swNoSpace:
+		return "smart card returned status 6a84 (not enough space)"
+	default:
+		return fmt.Sprintf("smart card returned status %04x", e.SW)
+	}
+}
+
+type apdu struct {
+	cla  byte
+	ins  byte
+	p1   byte
+	p2   byte
+	data []byte
+}
+
+// toBytes encodes a short command APDU.
+func (a apdu) toBytes() []byte {
+	b := []byte{a.cla, a.ins, a.p1, a.p2}
+	if len(a.data) > 0 {
+		b = append(b, byte(len(a.data)))
+		b = append(b, a.data...)
+	}
+	return b
+}
+
+// transmit sends a command to the card and returns the response data. If the card has more data
+// than fits in one response, the rest is requested with sendRemaining.
+func transmit(card SmartCard, a apdu, sendRemaining byte) ([]byte, error) {
+	if len(a.data) > maxApduDataSize {
+		return nil, fmt.Errorf("expected command data to be no more than %d bytes", maxApduDataSize)
+	}
+
+	resp, err := card.Transmit(a.toBytes())
+	var data []byte
+	for {
+		if err != nil {
+			return nil, fmt.Errorf("card.Transmit: %w", err)
+		}
+		if len(resp) < 2 {
+			return nil, fmt.Errorf("invalid response from smart card")
+		}
+
+		data = append(data, resp[:len(resp)-2]...)
+		sw := uint16(resp[len(resp)-2])<<8 | uint16(resp[len(resp)-1])
+		if sw>>8 == swMoreDataAvailable {
+			resp, err = card.Transmit(apdu{ins: sendRemaining}.toBytes())
+			continue
+		}
+		if sw != swSuccess {
+			return nil, StatusError{sw}
+		}
+
+		return data, nil
+	}
+}
+
+const maxApduDataSize = 255
+
+const (
+	swSuccess                    uint16 = 0x9000
+	swMoreDataAvailable          uint16 = 0x61
+	swSecurityStatusNotSatisfied uint16 = 0x6982
+	swNoSuchObject               uint16 = 0x6984
+	swWrongData                  uint16 = 0x6a80
+	swNoSpace                    uint16 = 0x6a84
+	swInsNotSupported            uint16 = 0x6d00
+)
@@ -0,0 +1,224 @@
+package yubikey
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/google/gousb"
+)
+
+// OpenSmartCard opens the CCID (smart card) interface of a YubiKey directly over USB. If serial is
+// empty, the first YubiKey with a CCID interface is used.
+//
+// This doesn't go through PC/SC, so it fails if a PC/SC daemon such as pcscd has claimed the
+// interface.
+func OpenSmartCard(serial string) (SmartCard, error) {
+	ctx := gousb.NewContext()
+	yks, err := findYubikeys(ctx)
+	if err != nil {
+		_ = ctx.Close()
+		return nil, fmt.Errorf("findYubikeys: %w", err)
+	}
+
+	var card *ccidCard
+	for _, yk := range yks {
+		if card == nil && (serial == "" || yk.serialMatches(serial)) {
+			card, err = openCcid(yk.t.(*usbTransport).dev)
+			if err == nil {
+				card.ctx = ctx
+				continue
+			}
+			card = nil
+		}
+		_ = yk.Close()
+	}
+
+	if card == nil {
+		_ = ctx.Close()
+		if err != nil {
+			return nil, fmt.Errorf("openCcid: %w", err)
+		}
+		return nil, fmt.Errorf("%w (no YubiKeys with a smart card interface found)", ErrYubikeyNotFound)
+	}
+
+	return card, nil
+}
+
+func (y *usbHidYubiKey) serialMatches(serial string) bool {
+	s, err := y.Serial()
+	return err == nil && s == serial
+}
+
+func openCcid(dev *gousb.Device) (*ccidCard, error) {
+	for _, cfgDesc := range dev.Desc.Configs {
+		for _, intfDesc := range cfgDesc.Interfaces {
+			for _, alt := range intfDesc.AltSettings {
+				if alt.Class != gousb.ClassSmartCard {
+					continue
+				}
+
+				c := ccidCard{dev: dev}
+				err := c.claim(cfgDesc.Number, alt)
+				if err != nil {
+					c.release()
+					return nil, err
+				}
+				err = c.powerOn()
+				if err != nil {
+					_ = c.Close()
+					return nil, fmt.Errorf("powerOn: %w", err)
+				}
+				return &c, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("YubiKey has no smart card interface")
+}
+
+// Transmit sends an APDU in a PC_to_RDR_XfrBlock message and returns the response APDU.
+func (c *ccidCard) Transmit(command []byte) ([]byte, error) {
+	return c.exchange(ccidXfrBlock, [3]byte{}, command)
+}
+
+func (c *ccidCard) Close() error {
+	c.release()
+	err := c.dev.Close()
+	if c.ctx != nil {
+		_ = c.ctx.Close()
+	}
+	return err
+}
+
+func (c *ccidCard) claim(cfgNum int, alt gousb.InterfaceSetting) error {
+	err := c.dev.SetAutoDetach(true)
+	if err != nil {
+		return fmt.Errorf("dev.SetAutoDetach: %w", err)
+	}
+	c.cfg, err = c.dev.Config(cfgNum)
+	if err != nil {
+		return fmt.Errorf("dev.Config: %w", err)
+	}
+	c.intf, err = c.cfg.Interface(alt.Number, alt.Alternate)
+	if err != nil {
+		return fmt.Errorf("cfg.Interface: %w", err)
+	}
+
+	for _, ep := range alt.Endpoints {
+		if ep.TransferType != gousb.TransferTypeBulk {
+			continue
+		}
+		if ep.Direction == gousb.EndpointDirectionIn {
+			c.in, err = c.intf.InEndpoint(ep.Number)
+		} else {
+			c.out, err = c.intf.OutEndpoint(ep.Number)
+		}
+		if err != nil {
+			return fmt.Errorf("open endpoint: %w", err)
+		}
+	}
+	if c.in == nil || c.out == nil {
+		return fmt.Errorf("smart card interface is missing bulk endpoints")
+	}
+	return nil
+}
+
+func (c *ccidCard) release() {
+	if c.intf != nil {
+		c.intf.Close()
+		c.intf = nil
+	}
+	if c.cfg != nil {
+		_ = c.cfg.Close()
+		c.cfg = nil
+	}
+}
+
+func (c *ccidCard) powerOn() error {
+	_, err := c.exchange(ccidIccPowerOn, [3]byte{}, nil)
+	return err
+}
+
+// exchange sends a CCID message and waits for the reader's response, which is repeated while the
+// card requests more time (e.g. while it waits for a touch).
+func (c *ccidCard) exchange(msgType byte, params [3]byte, data []byte) ([]byte, error) {
+	seq := c.seq
+	c.seq++
+
+	msg := make([]byte, ccidHeaderSize, ccidHeaderSize+len(data))
+	msg[0] = msgType
+	binary.LittleEndian.PutUint32(msg[1:], uint32(len(data)))
+	msg[6] = seq
+	copy(msg[7:], params[:])
+	msg = append(msg, data...)
+	_, err := c.out.Write(msg)
+	if err != nil {
+		return nil, fmt.Errorf("out.Write: %w", err)
+	}
+
+	for {
+		resp, err := c.readMessage()
+		if err != nil {
+			return nil, err
+		}
+		if resp[6] != seq {
+			continue
+		}
+
+		status := resp[7]
+		switch status & ccidCommandStatusMask {
+		case ccidCommandTimeExtension:
+			continue
+		case ccidCommandFailed:
+			return nil, fmt.Errorf("CCID command failed with error %#x", resp[8])
+		}
+		return resp[ccidHeaderSize:], nil
+	}
+}
+
+func (c *ccidCard) readMessage() ([]byte, error) {
+	buf := make([]byte, ccidMaxMessageSize)
+	n, err := c.in.Read(buf)
+	if err != nil {
+		return nil, fmt.Errorf("in.Read: %w", err)
+	}
+	if n < ccidHeaderSize || buf[0] != ccidDataBlock {
+		return nil, fmt.Errorf("invalid CCID response")
+	}
+
+	length := int(binary.LittleEndian.Uint32(buf[1:]))
+	if ccidHeaderSize+length > len(buf) {
+		return nil, fmt.Errorf("CCID response is too long")
+	}
+	for n < ccidHeaderSize+length {
+		m, err := c.in.Read(buf[n:])
+		if err != nil {
+			return nil, fmt.Errorf("in.Read: %w", err)
+		}
+		n += m
+	}
+
+	return buf[:ccidHeaderSize+length], nil
+}
+
+type ccidCard struct {
+	ctx  *gousb.Context
+	dev  *gousb.Device
+	cfg  *gousb.Config
+	intf *gousb.Interface
+	in   *gousb.InEndpoint
+	out  *gousb.OutEndpoint
+	seq  byte
+}
+
+const (
+	ccidHeaderSize     = 10
+	ccidMaxMessageSize = ccidHeaderSize + 65538
+
+	ccidIccPowerOn byte = 0x62
+	ccidXfrBlock   byte = 0x6f
+	ccidDataBlock  byte = 0x80
+
+	ccidCommandStatusMask    byte = 0xc0
+	ccidCommandFailed        byte = 0x40
+	ccidCommandTimeExtension byte = 0x80
+)
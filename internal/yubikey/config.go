@@ -0,0 +1,131 @@
+package yubikey
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// HmacSha1Config describes an HMAC-SHA1 challenge-response configuration for a YubiKey slot.
+//
+// Secret must be exactly 20 bytes. If RequireTouch is true, the YubiKey will wait for its button
+// to be touched before responding to a challenge. AccessCode is the slot's current access code,
+// if it has one, and NewAccessCode protects the slot from being reconfigured without it. Access
+// codes are at most 6 bytes.
+type HmacSha1Config struct {
+	Secret        []byte
+	RequireTouch  bool
+	AccessCode    []byte
+	NewAccessCode []byte
+}
+
+// slotConfig mirrors the YubiKey's config_st structure.
+//
+// See https://github.com/Yubico/yubikey-personalization/blob/master/ykcore/ykdef.h
+type slotConfig struct {
+	fixed     [fixedSize]byte
+	uid       [uidSize]byte
+	key       [keySize]byte
+	accCode   [accessCodeSize]byte
+	fixedSize byte
+	extFlags  byte
+	tktFlags  byte
+	cfgFlags  byte
+	rfu       [2]byte
+}
+
+func newHmacSha1SlotConfig(cfg HmacSha1Config) (slotConfig, error) {
+	if len(cfg.Secret) != hmacSha1SecretSize {
+		return slotConfig{}, fmt.Errorf("expected secret to be exactly %d bytes", hmacSha1SecretSize)
+	}
+	if len(cfg.NewAccessCode) > accessCodeSize {
+		return slotConfig{}, fmt.Errorf("expected access code to be no more than %d bytes", accessCodeSize)
+	}
+
+	c := slotConfig{
+		extFlags: extFlagSerialApiVisible | extFlagAllowUpdate,
+		tktFlags: tktFlagChalResp,
+		cfgFlags: cfgFlagChalHmac | cfgFlagHmacLt64,
+	}
+	if cfg.RequireTouch {
+		c.cfgFlags |= cfgFlagChalBtnTrig
+	}
+
+	// The first 16 bytes of the secret go in the key field, and the remaining 4 in the uid field.
+	copy(c.key[:], cfg.Secret[:keySize])
+	copy(c.uid[:], cfg.Secret[keySize:])
+	copy(c.accCode[:], cfg.NewAccessCode)
+
+	return c, nil
+}
+
+func (c slotConfig) toBytes() []byte {
+	b := make([]byte, 0, slotConfigSize)
+	b = append(b, c.fixed[:]...)
+	b = append(b, c.uid[:]...)
+	b = append(b, c.key[:]...)
+	b = append(b, c.accCode[:]...)
+	b = append(b, c.fixedSize, c.extFlags, c.tktFlags, c.cfgFlags)
+	b = append(b, c.rfu[:]...)
+	return binary.LittleEndian.AppendUint16(b, ^crc16(b))
+}
+
+func parseSlotConfig(b []byte) (slotConfig, error) {
+	if len(b) != slotConfigSize {
+		return slotConfig{}, fmt.Errorf("expected %d bytes of configuration", slotConfigSize)
+	}
+	if !verifyCrc(b) {
+		return slotConfig{}, fmt.Errorf("invalid checksum on configuration")
+	}
+
+	var c slotConfig
+	i := copy(c.fixed[:], b)
+	i += copy(c.uid[:], b[i:])
+	i += copy(c.key[:], b[i:])
+	i += copy(c.accCode[:], b[i:])
+	c.fixedSize, c.extFlags, c.tktFlags, c.cfgFlags = b[i], b[i+1], b[i+2], b[i+3]
+	copy(c.rfu[:], b[i+4:])
+
+	return c, nil
+}
+
+func (c slotConfig) isHmacSha1() bool {
+	return c.tktFlags&tktFlagChalResp != 0 && c.cfgFlags&cfgFlagChalHmac == cfgFlagChalHmac
+}
+
+func (c slotConfig) hmacSha1Secret() []byte {
+	secret := append([]byte{}, c.key[:]...)
+	return append(secret, c.uid[:hmacSha1SecretSize-keySize]...)
+}
+
+// slotConfigCommand returns the payload for a slot configuration command: the new configuration
+// followed by the slot's current access code.
+func slotConfigCommand(c slotConfig, accessCode []byte) ([]byte, error) {
+	if len(accessCode) > accessCodeSize {
+		return nil, fmt.Errorf("expected access code to be no more than %d bytes", accessCodeSize)
+	}
+	var code [accessCodeSize]byte
+	copy(code[:], accessCode)
+	return append(c.toBytes(), code[:]...), nil
+}
+
+const (
+	fixedSize          = 16
+	uidSize            = 6
+	keySize            = 16
+	accessCodeSize     = 6
+	slotConfigSize     = fixedSize + uidSize + keySize + accessCodeSize + 4 + 2 + 2
+	hmacSha1SecretSize = 20
+)
+
+const (
+	tktFlagAppendCr = 0x20
+	tktFlagChalResp = 0x40
+
+	cfgFlagStaticTicket = 0x20
+	cfgFlagChalHmac     = 0x22
+	cfgFlagHmacLt64     = 0x04
+	cfgFlagChalBtnTrig  = 0x08
+
+	extFlagSerialApiVisible = 0x04
+	extFlagAllowUpdate      = 0x20
+)
@@ -0,0 +1,41 @@
+package yubikey
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/internal/testutil"
+)
+
+func TestNewHmacSha1SlotConfig(t *testing.T) {
+	secret := testutil.UnHex("601598b189be7abaf383e5cb15f0429caf20da6a")
+
+	c, err := newHmacSha1SlotConfig(HmacSha1Config{Secret: secret, RequireTouch: true, NewAccessCode: []byte{1, 2, 3, 4, 5, 6}})
+	assert.Nil(t, err)
+
+	b := c.toBytes()
+	assert.Len(t, b, slotConfigSize)
+	assert.Equal(t, make([]byte, fixedSize), b[:16])
+	assert.Equal(t, testutil.UnHex("af20da6a0000"), b[16:22])
+	assert.Equal(t, testutil.UnHex("601598b189be7abaf383e5cb15f0429c"), b[22:38])
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6}, b[38:44])
+	assert.Equal(t, []byte{0, 0x24, 0x40, 0x2e, 0, 0}, b[44:50])
+	assert.True(t, verifyCrc(b))
+
+	parsed, err := parseSlotConfig(b)
+	assert.Nil(t, err)
+	assert.Equal(t, c, parsed)
+	assert.True(t, parsed.isHmacSha1())
+	assert.Equal(t, secret, parsed.hmacSha1Secret())
+
+	b[30] ^= 0xff
+	_, err = parseSlotConfig(b)
+	assert.NotNil(t, err)
+
+	_, err = newHmacSha1SlotConfig(HmacSha1Config{Secret: secret[:16]})
+	assert.NotNil(t, err)
+
+	_, err = newHmacSha1SlotConfig(HmacSha1Config{Secret: secret, NewAccessCode: make([]byte, 7)})
+	assert.NotNil(t, err)
+}
@@ -1,9 +1,14 @@
 package yubikey
 
 import (
+	"bytes"
+	"context"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha1"
+	"encoding/binary"
 	"fmt"
+	"time"
 )
 
 // NewEmulator returns a software implementation of the YubiKey interface.
@@ -15,7 +20,7 @@ func NewEmulator(secrets map[int][]byte) (YubiKey, error) {
 		if len(secret) != 20 {
 			return nil, fmt.Errorf("expected secret to be exactly 20 bytes")
 		}
-		yk.slots[slot] = emulatedSlot{secret}
+		yk.slots[slot] = emulatedSlot{secret: secret}
 	}
 
 	return &yk, nil
@@ -25,7 +30,10 @@ func NewEmulator(secrets map[int][]byte) (YubiKey, error) {
 //
 // Given a challenge and a secret from a YubiKey HMAC challenge-response slot, YubiHmacSha1 will
 // produce the same response as the YubiKey would.
-func (y *emulatedYubiKey) ChallengeResponseHmacSha1(slot int, challenge []byte) ([]byte, error) {
+func (y *emulatedYubiKey) ChallengeResponseHmacSha1(ctx context.Context, slot int, challenge []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	if len(challenge) > MaxChallengeLen {
 		return nil, fmt.Errorf("expected challenge to be no more than %d bytes", MaxChallengeLen)
 	}
@@ -41,12 +49,138 @@ func (y *emulatedYubiKey) ChallengeResponseHmacSha1(slot int, challenge []byte)
 		challenge = challenge[:i+1]
 	}
 
+	if y.slots[slot].requireTouch {
+		touchCallback(ctx)()
+	}
+
 	h := hmac.New(sha1.New, y.slots[slot].secret)
 	h.Write(challenge)
 
 	return h.Sum(nil), nil
 }
 
+// ProgramHmacSha1 configures the corresponding slot with an HMAC-SHA1 secret.
+//
+// The configuration is serialized and parsed back the same way a YubiKey receives it, and is
+// rejected if the slot is protected by an access code that doesn't match.
+func (y *emulatedYubiKey) ProgramHmacSha1(ctx context.Context, slot int, cfg HmacSha1Config) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if slot != 1 && slot != 2 {
+		return fmt.Errorf("invalid slot (expected 1 or 2)")
+	}
+
+	c, err := newHmacSha1SlotConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("newHmacSha1SlotConfig: %w", err)
+	}
+	payload, err := slotConfigCommand(c, cfg.AccessCode)
+	if err != nil {
+		return fmt.Errorf("slotConfigCommand: %w", err)
+	}
+
+	return y.writeConfig(slot, payload)
+}
+
+// ProgramYubicoOtp configures the corresponding slot to generate Yubico OTPs. Like a real
+// YubiKey, the usage counter starts at 1 and the session counter at 0.
+func (y *emulatedYubiKey) ProgramYubicoOtp(ctx context.Context, slot int, cfg YubicoOtpConfig) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if slot != 1 && slot != 2 {
+		return fmt.Errorf("invalid slot (expected 1 or 2)")
+	}
+
+	c, err := newYubicoOtpSlotConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("newYubicoOtpSlotConfig: %w", err)
+	}
+	payload, err := slotConfigCommand(c, cfg.AccessCode)
+	if err != nil {
+		return fmt.Errorf("slotConfigCommand: %w", err)
+	}
+
+	return y.writeConfig(slot, payload)
+}
+
+// YubicoOtp returns the next OTP from a slot configured with ProgramYubicoOtp, as if its button
+// had been touched.
+func (y *emulatedYubiKey) YubicoOtp(slot int) (string, error) {
+	s, found := y.slots[slot]
+	if !found || s.otp == nil {
+		return "", fmt.Errorf("slot %d is not configured for Yubico OTP", slot)
+	}
+
+	var r [2]byte
+	_, err := rand.Read(r[:])
+	if err != nil {
+		return "", fmt.Errorf("rand.Read: %w", err)
+	}
+
+	o := s.otp
+	token := YubicoOtpToken{
+		PrivateId:      o.privateId,
+		UsageCounter:   o.usageCounter,
+		Timestamp:      (o.timestamp + uint32(time.Since(o.poweredOn)/(time.Second/8))) & 0xffffff,
+		SessionCounter: o.sessionCounter,
+		Random:         binary.LittleEndian.Uint16(r[:]),
+	}
+
+	if o.sessionCounter == 0xff {
+		// A YubiKey counts this as a new session.
+		o.usageCounter++
+		o.sessionCounter = 0
+	} else {
+		o.sessionCounter++
+	}
+
+	return EncodeYubicoOtp(o.publicId, o.aesKey, token)
+}
+
+func (y *emulatedYubiKey) writeConfig(slot int, payload []byte) error {
+	c, err := parseSlotConfig(payload[:slotConfigSize])
+	if err != nil {
+		return fmt.Errorf("parseSlotConfig: %w", err)
+	}
+
+	current := y.slots[slot]
+	if !bytes.Equal(current.accessCode[:], payload[slotConfigSize:]) {
+		return fmt.Errorf("configuration was not applied (is the access code correct?)")
+	}
+
+	switch {
+	case c.isHmacSha1():
+		y.slots[slot] = emulatedSlot{
+			secret:       c.hmacSha1Secret(),
+			requireTouch: c.cfgFlags&cfgFlagChalBtnTrig != 0,
+			accessCode:   c.accCode,
+		}
+	case c.isYubicoOtp():
+		var timestamp [3]byte
+		_, err = rand.Read(timestamp[:])
+		if err != nil {
+			return fmt.Errorf("rand.Read: %w", err)
+		}
+		y.slots[slot] = emulatedSlot{
+			accessCode: c.accCode,
+			otp: &emulatedOtp{
+				publicId:     append([]byte{}, c.fixed[:c.fixedSize]...),
+				privateId:    append([]byte{}, c.uid[:]...),
+				aesKey:       append([]byte{}, c.key[:]...),
+				usageCounter: 1,
+				timestamp:    uint32(timestamp[0]) | uint32(timestamp[1])<<8 | uint32(timestamp[2])<<16,
+				poweredOn:    time.Now(),
+			},
+		}
+	default:
+		return fmt.Errorf("unsupported slot configuration")
+	}
+
+	return nil
+}
+
 func (y *emulatedYubiKey) Serial() (string, error) {
 	return "000000", nil
 }
@@ -68,5 +202,18 @@ type emulatedYubiKey struct {
 }
 
 type emulatedSlot struct {
-	secret []byte
+	secret       []byte
+	requireTouch bool
+	accessCode   [accessCodeSize]byte
+	otp          *emulatedOtp
+}
+
+type emulatedOtp struct {
+	publicId       []byte
+	privateId      []byte
+	aesKey         []byte
+	usageCounter   uint16
+	sessionCounter uint8
+	timestamp      uint32
+	poweredOn      time.Time
 }
@@ -2,6 +2,7 @@ package yubikey
 
 import (
 	"bytes"
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -81,7 +82,7 @@ func TestEmulatedYubiKey_ChallengeResponseHmacSha1(t *testing.T) {
 		{[]byte("this challenge is too long and should be rejected by the yubikey."), nil, true},
 	}
 	for _, tc := range testCases {
-		actual, err := yk.ChallengeResponseHmacSha1(1, tc.challenge)
+		actual, err := yk.ChallengeResponseHmacSha1(context.Background(), 1, tc.challenge)
 		if tc.expectErr {
 			assert.NotNil(t, err)
 		} else {
@@ -90,3 +91,52 @@ func TestEmulatedYubiKey_ChallengeResponseHmacSha1(t *testing.T) {
 		assert.Equal(t, tc.expected, actual)
 	}
 }
+
+func TestEmulatedYubiKey_ProgramHmacSha1(t *testing.T) {
+	yk, _ := NewEmulator(map[int][]byte{1: testutil.UnHex("601598b189be7abaf383e5cb15f0429caf20da6a")})
+	secret := testutil.UnHex("10dcf4302055070304ea2acce986f46bb0bc1524")
+	accessCode := []byte("secret")
+
+	err := yk.ProgramHmacSha1(context.Background(), 2, HmacSha1Config{Secret: secret, RequireTouch: true, NewAccessCode: accessCode})
+	assert.Nil(t, err)
+
+	actual, err := yk.ChallengeResponseHmacSha1(context.Background(), 2, []byte("hunter2"))
+	assert.Nil(t, err)
+	assert.Equal(t, testutil.UnHex("82edc0e3e5aeaa268cbdd8ec191d962f8259daf0"), actual)
+
+	// The slot is now protected by an access code.
+	err = yk.ProgramHmacSha1(context.Background(), 2, HmacSha1Config{Secret: secret})
+	assert.NotNil(t, err)
+
+	err = yk.ProgramHmacSha1(context.Background(), 2, HmacSha1Config{Secret: testutil.UnHex("601598b189be7abaf383e5cb15f0429caf20da6a"), AccessCode: accessCode})
+	assert.Nil(t, err)
+	actual, _ = yk.ChallengeResponseHmacSha1(context.Background(), 2, []byte("hunter2"))
+	expected, _ := yk.ChallengeResponseHmacSha1(context.Background(), 1, []byte("hunter2"))
+	assert.Equal(t, expected, actual)
+
+	err = yk.ProgramHmacSha1(context.Background(), 3, HmacSha1Config{Secret: secret})
+	assert.NotNil(t, err)
+
+	err = yk.ProgramHmacSha1(context.Background(), 1, HmacSha1Config{Secret: secret[:10]})
+	assert.NotNil(t, err)
+}
+
+func TestEmulatedYubiKey_context(t *testing.T) {
+	secret := testutil.UnHex("601598b189be7abaf383e5cb15f0429caf20da6a")
+	yk, _ := NewEmulator(map[int][]byte{1: secret})
+	_ = yk.ProgramHmacSha1(context.Background(), 2, HmacSha1Config{Secret: secret, RequireTouch: true})
+
+	touched := 0
+	ctx := WithTouchCallback(context.Background(), func() { touched++ })
+	_, _ = yk.ChallengeResponseHmacSha1(ctx, 1, []byte("hunter2"))
+	assert.Equal(t, 0, touched)
+	_, _ = yk.ChallengeResponseHmacSha1(ctx, 2, []byte("hunter2"))
+	assert.Equal(t, 1, touched)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := yk.ChallengeResponseHmacSha1(ctx, 1, []byte("hunter2"))
+	assert.ErrorIs(t, err, context.Canceled)
+	err = yk.ProgramHmacSha1(ctx, 1, HmacSha1Config{Secret: secret})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,153 @@
+package yubikey
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// SaveEmulator writes the HMAC-SHA1 secrets for an emulated YubiKey to a file, encrypted with a
+// key derived from passphrase. The file can be loaded with OpenEmulatorFile.
+func SaveEmulator(path string, passphrase []byte, secrets map[int][]byte) error {
+	contents := emulatorFileContents{Slots: make(map[string]string, len(secrets))}
+	for slot, secret := range secrets {
+		if slot != 1 && slot != 2 {
+			return fmt.Errorf("invalid slot (expected 1 or 2)")
+		}
+		if len(secret) != hmacSha1SecretSize {
+			return fmt.Errorf("expected secret to be exactly %d bytes", hmacSha1SecretSize)
+		}
+		contents.Slots[strconv.Itoa(slot)] = hex.EncodeToString(secret)
+	}
+	plaintext, err := json.Marshal(contents)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	f := emulatorFile{
+		Version: emulatorFileVersion,
+		Kdf: emulatorFileKdf{
+			Name: "scrypt",
+			Salt: make([]byte, 16),
+			N:    1 << 15,
+			R:    8,
+			P:    1,
+		},
+		Nonce: make([]byte, chacha20poly1305.NonceSizeX),
+	}
+	_, err = rand.Read(f.Kdf.Salt)
+	if err != nil {
+		return fmt.Errorf("rand.Read: %w", err)
+	}
+	_, err = rand.Read(f.Nonce)
+	if err != nil {
+		return fmt.Errorf("rand.Read: %w", err)
+	}
+
+	aead, err := f.aead(passphrase)
+	if err != nil {
+		return err
+	}
+	f.Ciphertext = aead.Seal(nil, f.Nonce, plaintext, nil)
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.MarshalIndent: %w", err)
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// OpenEmulatorFile returns an emulated YubiKey using the secrets in a file written by SaveEmulator.
+func OpenEmulatorFile(path string, passphrase []byte) (YubiKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	var f emulatorFile
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	if f.Version != emulatorFileVersion {
+		return nil, fmt.Errorf("unsupported emulator file version %d", f.Version)
+	}
+	if len(f.Nonce) != chacha20poly1305.NonceSizeX {
+		return nil, fmt.Errorf("invalid nonce in emulator file")
+	}
+
+	aead, err := f.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("incorrect passphrase or corrupted emulator file")
+	}
+
+	var contents emulatorFileContents
+	err = json.Unmarshal(plaintext, &contents)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	secrets := make(map[int][]byte, len(contents.Slots))
+	for s, secret := range contents.Slots {
+		slot, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slot %q in emulator file", s)
+		}
+		secrets[slot], err = hex.DecodeString(secret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret for slot %d in emulator file", slot)
+		}
+	}
+
+	return NewEmulator(secrets)
+}
+
+func (f *emulatorFile) aead(passphrase []byte) (cipher.AEAD, error) {
+	if f.Kdf.Name != "scrypt" {
+		return nil, fmt.Errorf("unsupported key derivation function %q", f.Kdf.Name)
+	}
+	key, err := scrypt.Key(passphrase, f.Kdf.Salt, f.Kdf.N, f.Kdf.R, f.Kdf.P, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("scrypt.Key: %w", err)
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("chacha20poly1305.NewX: %w", err)
+	}
+	return aead, nil
+}
+
+type emulatorFile struct {
+	Version    int             `json:"version"`
+	Kdf        emulatorFileKdf `json:"kdf"`
+	Nonce      []byte          `json:"nonce"`
+	Ciphertext []byte          `json:"ciphertext"`
+}
+
+type emulatorFileKdf struct {
+	Name string `json:"name"`
+	Salt []byte `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+// emulatorFileContents is the plaintext of an emulator file. Secrets are hex-encoded and keyed by
+// slot number.
+type emulatorFileContents struct {
+	Slots map[string]string `json:"slots"`
+}
+
+const emulatorFileVersion = 1
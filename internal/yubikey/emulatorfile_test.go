@@ -0,0 +1,60 @@
+package yubikey
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/internal/testutil"
+)
+
+func TestSaveEmulator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "yubikey.json")
+	secret := testutil.UnHex("601598b189be7abaf383e5cb15f0429caf20da6a")
+
+	err := SaveEmulator(path, []byte("correct horse"), map[int][]byte{2: secret})
+	assert.Nil(t, err)
+
+	data, _ := os.ReadFile(path)
+	assert.NotContains(t, string(data), "601598b189be7abaf383e5cb15f0429caf20da6a")
+
+	yk, err := OpenEmulatorFile(path, []byte("correct horse"))
+	assert.Nil(t, err)
+	expected, _ := NewEmulator(map[int][]byte{2: secret})
+	for _, challenge := range []string{"", "hunter2", "password"} {
+		actual, err := yk.ChallengeResponseHmacSha1(context.Background(), 2, []byte(challenge))
+		assert.Nil(t, err)
+		e, _ := expected.ChallengeResponseHmacSha1(context.Background(), 2, []byte(challenge))
+		assert.Equal(t, e, actual)
+	}
+
+	_, err = OpenEmulatorFile(path, []byte("incorrect horse"))
+	assert.NotNil(t, err)
+
+	_, err = OpenEmulatorFile(filepath.Join(t.TempDir(), "missing.json"), []byte("correct horse"))
+	assert.NotNil(t, err)
+
+	err = SaveEmulator(path, []byte("correct horse"), map[int][]byte{3: secret})
+	assert.NotNil(t, err)
+
+	err = SaveEmulator(path, []byte("correct horse"), map[int][]byte{1: secret[:10]})
+	assert.NotNil(t, err)
+}
+
+func TestOpenEmulatorFile(t *testing.T) {
+	// Written by SaveEmulator with the passphrase "correct horse".
+	yk, err := OpenEmulatorFile("testdata/emulator.json", []byte("correct horse"))
+	assert.Nil(t, err)
+	if yk == nil {
+		return
+	}
+
+	actual, err := yk.ChallengeResponseHmacSha1(context.Background(), 2, []byte("hunter2"))
+	assert.Nil(t, err)
+	em, _ := NewEmulator(map[int][]byte{2: testutil.UnHex("601598b189be7abaf383e5cb15f0429caf20da6a")})
+	expected, _ := em.ChallengeResponseHmacSha1(context.Background(), 2, []byte("hunter2"))
+	assert.Equal(t, expected, actual)
+}
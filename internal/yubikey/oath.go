@@ -0,0 +1,510 @@
+package yubikey
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+
+	"notpass-go/pkg/otp"
+)
+
+// ErrOathPasswordRequired is returned by OathSession operations when the applet is protected by
+// a password that hasn't been validated.
+var ErrOathPasswordRequired = errors.New("OATH applet requires a password")
+
+// OathCredential is an OATH credential stored on a YubiKey.
+//
+// Name is the credential's identifier on the key, which encodes the issuer, account, and any
+// non-default TOTP period, e.g. "60/Example:alice@example.com".
+type OathCredential struct {
+	Name      string
+	Issuer    string
+	Account   string
+	Type      otp.Type
+	Algorithm otp.Algorithm
+	Period    time.Duration
+}
+
+// OathCode is a one-time password calculated by a YubiKey.
+//
+// Code is empty if the credential is an HOTP credential or requires touch, since calculating
+// those has side effects; use OathSession.Calculate to get their codes. Expires is zero for
+// HOTP codes.
+type OathCode struct {
+	Credential    OathCredential
+	Code          string
+	Expires       time.Time
+	TouchRequired bool
+}
+
+// OathSession talks to the YKOATH applet of a YubiKey.
+//
+// See https://developers.yubico.com/OATH/YKOATH_Protocol.html
+type OathSession struct {
+	card      SmartCard
+	version   string
+	salt      []byte
+	challenge []byte
+	algorithm otp.Algorithm
+}
+
+// NewOathSession selects the OATH applet on card. If the applet is password protected,
+// PasswordRequired returns true and Validate must be called before anything else.
+func NewOathSession(card SmartCard) (*OathSession, error) {
+	resp, err := transmit(card, apdu{ins: insSelect, p1: 0x04, data: oathAid}, insOathSendRemaining)
+	if err != nil {
+		return nil, fmt.Errorf("transmit: %w", err)
+	}
+	tlvs, err := parseTlvs(resp)
+	if err != nil {
+		return nil, fmt.Errorf("parseTlvs: %w", err)
+	}
+
+	s := OathSession{card: card, algorithm: otp.SHA1}
+	if v, found := findTlv(tlvs, tagVersion); found && len(v) == 3 {
+		s.version = fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+	}
+	s.salt, _ = findTlv(tlvs, tagName)
+	s.challenge, _ = findTlv(tlvs, tagChallenge)
+	if a, found := findTlv(tlvs, tagAlgorithm); found && len(a) == 1 {
+		s.algorithm, err = oathAlgorithm(a[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &s, nil
+}
+
+// Version returns the version of the OATH applet.
+func (s *OathSession) Version() string {
+	return s.version
+}
+
+// PasswordRequired reports whether Validate must be called before using the session.
+func (s *OathSession) PasswordRequired() bool {
+	return s.challenge != nil
+}
+
+// Validate unlocks a password-protected OATH applet. It also checks that the applet knows the
+// password, so a card that accepts any response is detected.
+func (s *OathSession) Validate(password string) error {
+	if !s.PasswordRequired() {
+		return nil
+	}
+
+	h, err := oathHash(s.algorithm)
+	if err != nil {
+		return err
+	}
+	key := pbkdf2.Key([]byte(password), s.salt, oathPbkdf2Iterations, oathKeySize, sha1.New)
+	mac := hmac.New(h, key)
+	mac.Write(s.challenge)
+	response := mac.Sum(nil)
+
+	challenge := make([]byte, 8)
+	_, err = rand.Read(challenge)
+	if err != nil {
+		return fmt.Errorf("rand.Read: %w", err)
+	}
+
+	resp, err := s.transmit(apdu{ins: insOathValidate, data: encodeTlvs(
+		tlv{tagResponse, response},
+		tlv{tagChallenge, challenge},
+	)})
+	if err != nil {
+		var se StatusError
+		if errors.As(err, &se) && se.SW == swWrongData {
+			return fmt.Errorf("incorrect OATH password")
+		}
+		return err
+	}
+
+	tlvs, err := parseTlvs(resp)
+	if err != nil {
+		return fmt.Errorf("parseTlvs: %w", err)
+	}
+	mac = hmac.New(h, key)
+	mac.Write(challenge)
+	if actual, _ := findTlv(tlvs, tagResponse); !hmac.Equal(mac.Sum(nil), actual) {
+		return fmt.Errorf("OATH applet returned an invalid response")
+	}
+
+	s.challenge = nil
+	return nil
+}
+
+// List returns the credentials stored on the YubiKey.
+func (s *OathSession) List() ([]OathCredential, error) {
+	resp, err := s.transmit(apdu{ins: insOathList})
+	if err != nil {
+		return nil, err
+	}
+	tlvs, err := parseTlvs(resp)
+	if err != nil {
+		return nil, fmt.Errorf("parseTlvs: %w", err)
+	}
+
+	var creds []OathCredential
+	for _, t := range tlvs {
+		if t.tag != tagNameList || len(t.value) < 1 {
+			continue
+		}
+		c, err := parseOathCredential(string(t.value[1:]), t.value[0])
+		if err != nil {
+			return nil, err
+		}
+		creds = append(creds, c)
+	}
+	return creds, nil
+}
+
+// Calculate returns the code for a credential. For TOTP credentials, the code is for time t. For
+// HOTP credentials, the YubiKey increments the credential's counter.
+func (s *OathSession) Calculate(c OathCredential, t time.Time) (OathCode, error) {
+	tlvs := []tlv{{tagName, []byte(c.Name)}}
+	code := OathCode{Credential: c}
+	if c.Type == otp.TOTP {
+		var challenge []byte
+		challenge, code.Expires = totpChallenge(t, c.Period)
+		tlvs = append(tlvs, tlv{tagChallenge, challenge})
+	} else {
+		tlvs = append(tlvs, tlv{tagChallenge, nil})
+	}
+
+	resp, err := s.transmit(apdu{ins: insOathCalculate, p2: 0x01, data: encodeTlvs(tlvs...)})
+	if err != nil {
+		return OathCode{}, err
+	}
+	respTlvs, err := parseTlvs(resp)
+	if err != nil {
+		return OathCode{}, fmt.Errorf("parseTlvs: %w", err)
+	}
+	v, found := findTlv(respTlvs, tagTruncatedResponse)
+	if !found {
+		return OathCode{}, fmt.Errorf("OATH applet did not return a code")
+	}
+	code.Code, err = formatOathCode(v)
+	if err != nil {
+		return OathCode{}, err
+	}
+
+	return code, nil
+}
+
+// CalculateAll returns the codes for all TOTP credentials at time t that don't require touch.
+// The other credentials are included without a code.
+func (s *OathSession) CalculateAll(t time.Time) ([]OathCode, error) {
+	challenge, _ := totpChallenge(t, defaultOathPeriod)
+	resp, err := s.transmit(apdu{ins: insOathCalculateAll, p2: 0x01, data: encodeTlvs(tlv{tagChallenge, challenge})})
+	if err != nil {
+		return nil, err
+	}
+	tlvs, err := parseTlvs(resp)
+	if err != nil {
+		return nil, fmt.Errorf("parseTlvs: %w", err)
+	}
+	if len(tlvs)%2 != 0 {
+		return nil, fmt.Errorf("invalid response from OATH applet")
+	}
+
+	var codes []OathCode
+	for i := 0; i < len(tlvs); i += 2 {
+		name, result := tlvs[i], tlvs[i+1]
+		if name.tag != tagName {
+			return nil, fmt.Errorf("invalid response from OATH applet")
+		}
+
+		typ := byte(oathTypeTotp)
+		if result.tag == tagHotp {
+			typ = oathTypeHotp
+		}
+		c, err := parseOathCredential(string(name.value), typ)
+		if err != nil {
+			return nil, err
+		}
+
+		code := OathCode{Credential: c}
+		switch result.tag {
+		case tagTouch:
+			code.TouchRequired = true
+		case tagTruncatedResponse:
+			if c.Period != defaultOathPeriod {
+				// The YubiKey used the default period for every credential, so recalculate.
+				code, err = s.Calculate(c, t)
+				if err != nil {
+					return nil, err
+				}
+			} else {
+				code.Code, err = formatOathCode(result.value)
+				if err != nil {
+					return nil, err
+				}
+				_, code.Expires = totpChallenge(t, c.Period)
+			}
+		}
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+// Put stores a credential on the YubiKey, replacing any credential with the same name. If
+// requireTouch is true, the YubiKey's button must be touched to calculate codes.
+func (s *OathSession) Put(k otp.Key, requireTouch bool) (OathCredential, error) {
+	if k.Type != otp.TOTP && k.Type != otp.HOTP {
+		return OathCredential{}, fmt.Errorf("invalid type: expected totp or hotp")
+	}
+	c := OathCredential{
+		Issuer:    k.Issuer,
+		Account:   k.Account,
+		Type:      k.Type,
+		Algorithm: k.Algorithm,
+		Period:    k.Period,
+	}
+	if c.Algorithm == "" {
+		c.Algorithm = otp.SHA1
+	}
+	if c.Type == otp.TOTP && c.Period == 0 {
+		c.Period = defaultOathPeriod
+	}
+	c.Name = oathCredentialName(c)
+	if len(c.Name) > maxOathNameLen {
+		return OathCredential{}, fmt.Errorf("expected name to be no more than %d bytes", maxOathNameLen)
+	}
+
+	typ, err := oathTypeByte(c.Type, c.Algorithm)
+	if err != nil {
+		return OathCredential{}, err
+	}
+	digits := k.Digits
+	if digits == 0 {
+		digits = 6
+	}
+	secret, err := oathSecret(k.Secret, c.Algorithm)
+	if err != nil {
+		return OathCredential{}, err
+	}
+
+	data := encodeTlvs(
+		tlv{tagName, []byte(c.Name)},
+		tlv{tagKey, append([]byte{typ, byte(digits)}, secret...)},
+	)
+	if requireTouch {
+		// The property tag is the one field that isn't encoded as a TLV.
+		data = append(data, tagProperty, propertyRequireTouch)
+	}
+	if c.Type == otp.HOTP && k.Counter > 0 {
+		data = append(data, tlv{tagImf, binary.BigEndian.AppendUint32(nil, uint32(k.Counter))}.toBytes()...)
+	}
+
+	_, err = s.transmit(apdu{ins: insOathPut, data: data})
+	if err != nil {
+		return OathCredential{}, err
+	}
+	return c, nil
+}
+
+// Delete removes the credential with the given name from the YubiKey.
+func (s *OathSession) Delete(name string) error {
+	_, err := s.transmit(apdu{ins: insOathDelete, data: encodeTlvs(tlv{tagName, []byte(name)})})
+	return err
+}
+
+func (s *OathSession) transmit(a apdu) ([]byte, error) {
+	resp, err := transmit(s.card, a, insOathSendRemaining)
+	var se StatusError
+	if errors.As(err, &se) && se.SW == swSecurityStatusNotSatisfied {
+		return nil, ErrOathPasswordRequired
+	}
+	return resp, err
+}
+
+// oathCredentialName returns the name that identifies a credential on the YubiKey.
+func oathCredentialName(c OathCredential) string {
+	name := c.Account
+	if c.Issuer != "" {
+		name = c.Issuer + ":" + name
+	}
+	if c.Type == otp.TOTP && c.Period != defaultOathPeriod {
+		name = strconv.Itoa(int(c.Period/time.Second)) + "/" + name
+	}
+	return name
+}
+
+func parseOathCredential(name string, typ byte) (OathCredential, error) {
+	c := OathCredential{Name: name}
+	switch typ & oathTypeMask {
+	case oathTypeHotp:
+		c.Type = otp.HOTP
+	case oathTypeTotp:
+		c.Type = otp.TOTP
+		c.Period = defaultOathPeriod
+	default:
+		return OathCredential{}, fmt.Errorf("unsupported OATH credential type %#x", typ&oathTypeMask)
+	}
+	if alg := typ & oathAlgorithmMask; alg != 0 {
+		var err error
+		c.Algorithm, err = oathAlgorithm(alg)
+		if err != nil {
+			return OathCredential{}, err
+		}
+	}
+
+	if c.Type == otp.TOTP {
+		if period, rest, found := strings.Cut(name, "/"); found {
+			if seconds, err := strconv.Atoi(period); err == nil && seconds > 0 {
+				c.Period = time.Duration(seconds) * time.Second
+				name = rest
+			}
+		}
+	}
+	if issuer, account, found := strings.Cut(name, ":"); found {
+		c.Issuer = issuer
+		c.Account = account
+	} else {
+		c.Account = name
+	}
+
+	return c, nil
+}
+
+func totpChallenge(t time.Time, period time.Duration) ([]byte, time.Time) {
+	seconds := int64(period / time.Second)
+	n := t.Unix() / seconds
+	return binary.BigEndian.AppendUint64(nil, uint64(n)), time.Unix((n+1)*seconds, 0)
+}
+
+func formatOathCode(v []byte) (string, error) {
+	if len(v) != 5 || v[0] < 6 || v[0] > 10 {
+		return "", fmt.Errorf("invalid code from OATH applet")
+	}
+	digits := int(v[0])
+	value := uint64(binary.BigEndian.Uint32(v[1:]) & 0x7fffffff)
+	mod := uint64(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod), nil
+}
+
+// oathSecret prepares a secret the way the YubiKey expects it: secrets longer than the hash's
+// block size are hashed, and short secrets are padded to the minimum length.
+func oathSecret(secret []byte, alg otp.Algorithm) ([]byte, error) {
+	h, err := oathHash(alg)
+	if err != nil {
+		return nil, err
+	}
+	if len(secret) > h().BlockSize() {
+		d := h()
+		d.Write(secret)
+		secret = d.Sum(nil)
+	}
+	if len(secret) < minOathSecretSize {
+		secret = append(append([]byte{}, secret...), make([]byte, minOathSecretSize-len(secret))...)
+	}
+	return secret, nil
+}
+
+func oathHash(alg otp.Algorithm) (func() hash.Hash, error) {
+	switch alg {
+	case otp.SHA1, "":
+		return sha1.New, nil
+	case otp.SHA256:
+		return sha256.New, nil
+	case otp.SHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %s", alg)
+	}
+}
+
+func oathAlgorithm(b byte) (otp.Algorithm, error) {
+	switch b {
+	case oathAlgorithmSha1:
+		return otp.SHA1, nil
+	case oathAlgorithmSha256:
+		return otp.SHA256, nil
+	case oathAlgorithmSha512:
+		return otp.SHA512, nil
+	default:
+		return "", fmt.Errorf("unsupported OATH algorithm %#x", b)
+	}
+}
+
+func oathTypeByte(typ otp.Type, alg otp.Algorithm) (byte, error) {
+	var b byte
+	switch alg {
+	case otp.SHA1:
+		b = oathAlgorithmSha1
+	case otp.SHA256:
+		b = oathAlgorithmSha256
+	case otp.SHA512:
+		b = oathAlgorithmSha512
+	default:
+		return 0, fmt.Errorf("unsupported algorithm %s", alg)
+	}
+	if typ == otp.HOTP {
+		return b | oathTypeHotp, nil
+	}
+	return b | oathTypeTotp, nil
+}
+
+var oathAid = []byte{0xa0, 0x00, 0x00, 0x05, 0x27, 0x21, 0x01}
+
+const (
+	defaultOathPeriod    = 30 * time.Second
+	maxOathNameLen       = 64
+	minOathSecretSize    = 14
+	oathKeySize          = 16
+	oathPbkdf2Iterations = 1000
+)
+
+const (
+	insSelect            byte = 0xa4
+	insOathPut           byte = 0x01
+	insOathDelete        byte = 0x02
+	insOathList          byte = 0xa1
+	insOathCalculate     byte = 0xa2
+	insOathValidate      byte = 0xa3
+	insOathCalculateAll  byte = 0xa4
+	insOathSendRemaining byte = 0xa5
+)
+
+const (
+	tagName              byte = 0x71
+	tagNameList          byte = 0x72
+	tagKey               byte = 0x73
+	tagChallenge         byte = 0x74
+	tagResponse          byte = 0x75
+	tagTruncatedResponse byte = 0x76
+	tagHotp              byte = 0x77
+	tagProperty          byte = 0x78
+	tagVersion           byte = 0x79
+	tagImf               byte = 0x7a
+	tagAlgorithm         byte = 0x7b
+	tagTouch             byte = 0x7c
+)
+
+const (
+	oathTypeMask        byte = 0xf0
+	oathTypeHotp        byte = 0x10
+	oathTypeTotp        byte = 0x20
+	oathAlgorithmMask   byte = 0x0f
+	oathAlgorithmSha1   byte = 0x01
+	oathAlgorithmSha256 byte = 0x02
+	oathAlgorithmSha512 byte = 0x03
+
+	propertyRequireTouch byte = 0x02
+)
@@ -0,0 +1,238 @@
+package yubikey
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"sort"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// oathSimulator is an in-process smart card that implements the YKOATH applet. Responses are split
+// into chunks of chunkSize bytes to exercise SEND REMAINING.
+type oathSimulator struct {
+	selected  bool
+	salt      []byte
+	key       []byte
+	unlocked  bool
+	challenge []byte
+	creds     map[string]*simulatedCredential
+	chunkSize int
+	pending   []byte
+	touched   int
+}
+
+type simulatedCredential struct {
+	typ     byte
+	digits  byte
+	secret  []byte
+	touch   bool
+	counter uint32
+}
+
+func newOathSimulator(password string) *oathSimulator {
+	s := oathSimulator{
+		salt:      []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		creds:     make(map[string]*simulatedCredential),
+		chunkSize: 64,
+	}
+	if password != "" {
+		s.key = pbkdf2.Key([]byte(password), s.salt, oathPbkdf2Iterations, oathKeySize, sha1.New)
+	}
+	return &s
+}
+
+func (s *oathSimulator) Transmit(command []byte) ([]byte, error) {
+	if len(command) < 4 {
+		return sw(swWrongData), nil
+	}
+	ins, p1 := command[1], command[2]
+	var data []byte
+	if len(command) > 5 {
+		data = command[5:]
+	}
+
+	if ins == insOathSendRemaining && s.pending != nil {
+		return s.respond(nil)
+	}
+	s.pending = nil
+
+	if ins == insSelect && p1 == 0x04 {
+		if string(data) != string(oathAid) {
+			return sw(swNoSuchObject), nil
+		}
+		s.selected = true
+		s.unlocked = s.key == nil
+		resp := encodeTlvs(tlv{tagVersion, []byte{5, 4, 3}}, tlv{tagName, s.salt})
+		if s.key != nil {
+			s.challenge = make([]byte, 8)
+			_, _ = rand.Read(s.challenge)
+			resp = append(resp, encodeTlvs(tlv{tagChallenge, s.challenge}, tlv{tagAlgorithm, []byte{oathAlgorithmSha1}})...)
+		}
+		return s.respond(resp)
+	}
+	if !s.selected {
+		return sw(swInsNotSupported), nil
+	}
+	if ins == insOathValidate {
+		return s.validate(data)
+	}
+	if !s.unlocked {
+		return sw(swSecurityStatusNotSatisfied), nil
+	}
+
+	switch ins {
+	case insOathPut:
+		return s.put(data)
+	case insOathDelete:
+		tlvs, _ := parseTlvs(data)
+		name, _ := findTlv(tlvs, tagName)
+		if _, found := s.creds[string(name)]; !found {
+			return sw(swNoSuchObject), nil
+		}
+		delete(s.creds, string(name))
+		return sw(swSuccess), nil
+	case insOathList:
+		var resp []byte
+		for _, name := range s.names() {
+			c := s.creds[name]
+			resp = append(resp, tlv{tagNameList, append([]byte{c.typ}, name...)}.toBytes()...)
+		}
+		return s.respond(resp)
+	case insOathCalculate:
+		tlvs, _ := parseTlvs(data)
+		name, _ := findTlv(tlvs, tagName)
+		challenge, _ := findTlv(tlvs, tagChallenge)
+		c, found := s.creds[string(name)]
+		if !found {
+			return sw(swNoSuchObject), nil
+		}
+		if c.touch {
+			s.touched++
+		}
+		return s.respond(tlv{tagTruncatedResponse, s.calculate(c, challenge)}.toBytes())
+	case insOathCalculateAll:
+		tlvs, _ := parseTlvs(data)
+		challenge, _ := findTlv(tlvs, tagChallenge)
+		var resp []byte
+		for _, name := range s.names() {
+			c := s.creds[name]
+			resp = append(resp, tlv{tagName, []byte(name)}.toBytes()...)
+			switch {
+			case c.typ&oathTypeMask == oathTypeHotp:
+				resp = append(resp, tlv{tagHotp, []byte{c.digits}}.toBytes()...)
+			case c.touch:
+				resp = append(resp, tlv{tagTouch, []byte{c.digits}}.toBytes()...)
+			default:
+				resp = append(resp, tlv{tagTruncatedResponse, s.calculate(c, challenge)}.toBytes()...)
+			}
+		}
+		return s.respond(resp)
+	default:
+		return sw(swInsNotSupported), nil
+	}
+}
+
+func (s *oathSimulator) validate(data []byte) ([]byte, error) {
+	tlvs, err := parseTlvs(data)
+	if err != nil || s.key == nil {
+		return sw(swWrongData), nil
+	}
+	response, _ := findTlv(tlvs, tagResponse)
+	challenge, _ := findTlv(tlvs, tagChallenge)
+
+	mac := hmac.New(sha1.New, s.key)
+	mac.Write(s.challenge)
+	if !hmac.Equal(mac.Sum(nil), response) {
+		return sw(swWrongData), nil
+	}
+	s.unlocked = true
+
+	mac = hmac.New(sha1.New, s.key)
+	mac.Write(challenge)
+	return s.respond(tlv{tagResponse, mac.Sum(nil)}.toBytes())
+}
+
+func (s *oathSimulator) put(data []byte) ([]byte, error) {
+	var name, key []byte
+	c := simulatedCredential{}
+	for len(data) > 0 {
+		tag := data[0]
+		if tag == tagProperty {
+			c.touch = data[1]&propertyRequireTouch != 0
+			data = data[2:]
+			continue
+		}
+		tlvs, err := parseTlvs(data[:2+int(data[1])])
+		if err != nil {
+			return sw(swWrongData), nil
+		}
+		data = data[2+int(data[1]):]
+		switch tag {
+		case tagName:
+			name = tlvs[0].value
+		case tagKey:
+			key = tlvs[0].value
+		case tagImf:
+			c.counter = binary.BigEndian.Uint32(tlvs[0].value)
+		}
+	}
+	if len(name) == 0 || len(key) < 2+minOathSecretSize {
+		return sw(swWrongData), nil
+	}
+	c.typ, c.digits, c.secret = key[0], key[1], key[2:]
+	s.creds[string(name)] = &c
+	return sw(swSuccess), nil
+}
+
+func (s *oathSimulator) calculate(c *simulatedCredential, challenge []byte) []byte {
+	alg, _ := oathAlgorithm(c.typ & oathAlgorithmMask)
+	h, _ := oathHash(alg)
+	counter := binary.BigEndian.AppendUint64(nil, uint64(c.counter))
+	if c.typ&oathTypeMask == oathTypeHotp {
+		c.counter++
+	} else {
+		counter = challenge
+	}
+	mac := hmac.New(h, c.secret)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return append([]byte{c.digits}, sum[offset:offset+4]...)
+}
+
+func (s *oathSimulator) names() []string {
+	var names []string
+	for name := range s.creds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// respond returns the first chunk of data, keeping the rest for SEND REMAINING.
+func (s *oathSimulator) respond(data []byte) ([]byte, error) {
+	if data == nil {
+		data = s.pending
+	}
+	if len(data) <= s.chunkSize {
+		s.pending = nil
+		return append(append([]byte{}, data...), sw(swSuccess)...), nil
+	}
+	s.pending = data[s.chunkSize:]
+	remaining := len(s.pending)
+	if remaining > 0xff {
+		remaining = 0
+	}
+	return append(append([]byte{}, data[:s.chunkSize]...), byte(swMoreDataAvailable), byte(remaining)), nil
+}
+
+func (s *oathSimulator) Close() error {
+	return nil
+}
+
+func sw(sw uint16) []byte {
+	return []byte{byte(sw >> 8), byte(sw)}
+}
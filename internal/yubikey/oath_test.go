@@ -0,0 +1,157 @@
+package yubikey
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/pkg/otp"
+)
+
+// The RFC 4226 and RFC 6238 test secret.
+var rfcSecret = []byte("12345678901234567890")
+
+func TestOathSession(t *testing.T) {
+	card := newOathSimulator("")
+	card.chunkSize = 16
+	s, err := NewOathSession(card)
+	assert.Nil(t, err)
+	if s == nil {
+		return
+	}
+	assert.Equal(t, "5.4.3", s.Version())
+	assert.False(t, s.PasswordRequired())
+
+	totp, err := s.Put(otp.Key{Type: otp.TOTP, Secret: rfcSecret, Digits: 8, Issuer: "Example", Account: "alice"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "Example:alice", totp.Name)
+	slow, err := s.Put(otp.Key{Type: otp.TOTP, Secret: rfcSecret, Digits: 8, Period: 60 * time.Second, Account: "slow"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "60/slow", slow.Name)
+	_, err = s.Put(otp.Key{Type: otp.HOTP, Secret: rfcSecret, Account: "counter"}, false)
+	assert.Nil(t, err)
+	_, err = s.Put(otp.Key{Type: otp.TOTP, Secret: rfcSecret, Algorithm: otp.SHA256, Account: "touch"}, true)
+	assert.Nil(t, err)
+
+	creds, err := s.List()
+	assert.Nil(t, err)
+	assert.Equal(t, []OathCredential{
+		{Name: "60/slow", Account: "slow", Type: otp.TOTP, Algorithm: otp.SHA1, Period: 60 * time.Second},
+		{Name: "Example:alice", Issuer: "Example", Account: "alice", Type: otp.TOTP, Algorithm: otp.SHA1, Period: 30 * time.Second},
+		{Name: "counter", Account: "counter", Type: otp.HOTP, Algorithm: otp.SHA1},
+		{Name: "touch", Account: "touch", Type: otp.TOTP, Algorithm: otp.SHA256, Period: 30 * time.Second},
+	}, creds)
+
+	code, err := s.Calculate(creds[1], time.Unix(59, 0))
+	assert.Nil(t, err)
+	assert.Equal(t, "94287082", code.Code)
+	assert.Equal(t, time.Unix(60, 0), code.Expires)
+
+	code, err = s.Calculate(creds[2], time.Time{})
+	assert.Nil(t, err)
+	assert.Equal(t, "755224", code.Code)
+	code, err = s.Calculate(creds[2], time.Time{})
+	assert.Nil(t, err)
+	assert.Equal(t, "287082", code.Code)
+
+	codes, err := s.CalculateAll(time.Unix(1111111109, 0))
+	assert.Nil(t, err)
+	assert.Len(t, codes, 4)
+	if len(codes) == 4 {
+		expected, _, _ := otp.Key{Type: otp.TOTP, Secret: rfcSecret, Digits: 8, Period: 60 * time.Second}.Code(time.Unix(1111111109, 0))
+		assert.Equal(t, expected, codes[0].Code)
+		assert.Equal(t, "07081804", codes[1].Code)
+		assert.Equal(t, time.Unix(1111111110, 0), codes[1].Expires)
+		assert.Equal(t, otp.HOTP, codes[2].Credential.Type)
+		assert.Empty(t, codes[2].Code)
+		assert.True(t, codes[3].TouchRequired)
+		assert.Empty(t, codes[3].Code)
+	}
+	assert.Equal(t, 0, card.touched)
+
+	code, err = s.Calculate(creds[3], time.Unix(59, 0))
+	assert.Nil(t, err)
+	assert.Len(t, code.Code, 6)
+	assert.Equal(t, 1, card.touched)
+
+	err = s.Delete("counter")
+	assert.Nil(t, err)
+	err = s.Delete("counter")
+	assert.ErrorIs(t, err, StatusError{swNoSuchObject})
+	creds, _ = s.List()
+	assert.Len(t, creds, 3)
+
+	_, err = s.Calculate(OathCredential{Name: "missing", Type: otp.TOTP, Period: 30 * time.Second}, time.Now())
+	assert.NotNil(t, err)
+}
+
+func TestOathSession_password(t *testing.T) {
+	card := newOathSimulator("hunter2")
+
+	s, err := NewOathSession(card)
+	assert.Nil(t, err)
+	assert.True(t, s.PasswordRequired())
+	_, err = s.List()
+	assert.ErrorIs(t, err, ErrOathPasswordRequired)
+
+	err = s.Validate("hunter3")
+	assert.NotNil(t, err)
+	assert.True(t, s.PasswordRequired())
+
+	s, _ = NewOathSession(card)
+	err = s.Validate("hunter2")
+	assert.Nil(t, err)
+	assert.False(t, s.PasswordRequired())
+	_, err = s.List()
+	assert.Nil(t, err)
+}
+
+func TestOathSession_Put_errors(t *testing.T) {
+	s, _ := NewOathSession(newOathSimulator(""))
+
+	_, err := s.Put(otp.Key{Secret: rfcSecret, Account: "alice"}, false)
+	assert.NotNil(t, err)
+	_, err = s.Put(otp.Key{Type: otp.TOTP, Secret: rfcSecret, Algorithm: "MD5", Account: "alice"}, false)
+	assert.NotNil(t, err)
+	_, err = s.Put(otp.Key{Type: otp.TOTP, Secret: rfcSecret, Account: string(make([]byte, 65))}, false)
+	assert.NotNil(t, err)
+}
+
+func TestParseOathCredential(t *testing.T) {
+	testCases := []struct {
+		name     string
+		typ      byte
+		expected OathCredential
+	}{
+		{"alice", 0x21, OathCredential{Name: "alice", Account: "alice", Type: otp.TOTP, Algorithm: otp.SHA1, Period: 30 * time.Second}},
+		{"Example:alice", 0x12, OathCredential{Name: "Example:alice", Issuer: "Example", Account: "alice", Type: otp.HOTP, Algorithm: otp.SHA256}},
+		{"15/Example:alice", 0x23, OathCredential{Name: "15/Example:alice", Issuer: "Example", Account: "alice", Type: otp.TOTP, Algorithm: otp.SHA512, Period: 15 * time.Second}},
+		{"a/b", 0x21, OathCredential{Name: "a/b", Account: "a/b", Type: otp.TOTP, Algorithm: otp.SHA1, Period: 30 * time.Second}},
+		{"15/alice", 0x11, OathCredential{Name: "15/alice", Account: "15/alice", Type: otp.HOTP, Algorithm: otp.SHA1}},
+	}
+	for _, tc := range testCases {
+		c, err := parseOathCredential(tc.name, tc.typ)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.expected, c)
+		assert.Equal(t, tc.name, oathCredentialName(c))
+	}
+
+	_, err := parseOathCredential("alice", 0x31)
+	assert.NotNil(t, err)
+}
+
+func TestNewOathSession_notSupported(t *testing.T) {
+	_, err := NewOathSession(&rejectingCard{})
+	assert.ErrorIs(t, err, StatusError{swInsNotSupported})
+}
+
+type rejectingCard struct{}
+
+func (c *rejectingCard) Transmit([]byte) ([]byte, error) {
+	return sw(swInsNotSupported), nil
+}
+
+func (c *rejectingCard) Close() error {
+	return nil
+}
@@ -0,0 +1,77 @@
+package yubikey
+
+import "fmt"
+
+// tlv is a BER-TLV data object with a one-byte tag, as used by the YubiKey applets.
+type tlv struct {
+	tag   byte
+	value []byte
+}
+
+func (t tlv) toBytes() []byte {
+	b := []byte{t.tag}
+	switch n := len(t.value); {
+	case n < 0x80:
+		b = append(b, byte(n))
+	case n <= 0xff:
+		b = append(b, 0x81, byte(n))
+	default:
+		b = append(b, 0x82, byte(n>>8), byte(n))
+	}
+	return append(b, t.value...)
+}
+
+func encodeTlvs(tlvs ...tlv) []byte {
+	var b []byte
+	for _, t := range tlvs {
+		b = append(b, t.toBytes()...)
+	}
+	return b
+}
+
+func parseTlvs(b []byte) ([]tlv, error) {
+	var tlvs []tlv
+	for len(b) > 0 {
+		if len(b) < 2 {
+			return nil, fmt.Errorf("truncated TLV")
+		}
+		t := tlv{tag: b[0]}
+		n := int(b[1])
+		b = b[2:]
+		switch n {
+		case 0x81:
+			if len(b) < 1 {
+				return nil, fmt.Errorf("truncated TLV")
+			}
+			n = int(b[0])
+			b = b[1:]
+		case 0x82:
+			if len(b) < 2 {
+				return nil, fmt.Errorf("truncated TLV")
+			}
+			n = int(b[0])<<8 | int(b[1])
+			b = b[2:]
+		default:
+			if n > 0x7f {
+				return nil, fmt.Errorf("unsupported TLV length")
+			}
+		}
+		if len(b) < n {
+			return nil, fmt.Errorf("truncated TLV")
+		}
+		t.value = b[:n]
+		b = b[n:]
+		tlvs = append(tlvs, t)
+	}
+	return tlvs, nil
+}
+
+// findTlv returns the value of the first data object with the given tag.
+func findTlv(tlvs []tlv, tag byte) ([]byte, bool) {
+	for _, t := range tlvs {
+		if t.tag == tag {
+			return t.value, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,43 @@
+package yubikey
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTlv(t *testing.T) {
+	testCases := []struct {
+		tlv      tlv
+		expected []byte
+	}{
+		{tlv{0x71, nil}, []byte{0x71, 0x00}},
+		{tlv{0x71, []byte("abc")}, []byte{0x71, 0x03, 'a', 'b', 'c'}},
+		{tlv{0x72, bytes.Repeat([]byte{1}, 0x80)}, append([]byte{0x72, 0x81, 0x80}, bytes.Repeat([]byte{1}, 0x80)...)},
+		{tlv{0x73, bytes.Repeat([]byte{2}, 0x100)}, append([]byte{0x73, 0x82, 0x01, 0x00}, bytes.Repeat([]byte{2}, 0x100)...)},
+	}
+	for _, tc := range testCases {
+		b := tc.tlv.toBytes()
+		assert.Equal(t, tc.expected, b)
+
+		parsed, err := parseTlvs(b)
+		assert.Nil(t, err)
+		assert.Len(t, parsed, 1)
+		assert.Equal(t, tc.tlv.tag, parsed[0].tag)
+		assert.Equal(t, len(tc.tlv.value), len(parsed[0].value))
+	}
+
+	tlvs, err := parseTlvs(encodeTlvs(tlv{0x71, []byte("a")}, tlv{0x74, []byte("bc")}))
+	assert.Nil(t, err)
+	v, found := findTlv(tlvs, 0x74)
+	assert.True(t, found)
+	assert.Equal(t, []byte("bc"), v)
+	_, found = findTlv(tlvs, 0x75)
+	assert.False(t, found)
+
+	for _, b := range [][]byte{{0x71}, {0x71, 0x02, 0x00}, {0x71, 0x81}, {0x71, 0x82, 0x00}, {0x71, 0x83, 0, 0, 1}} {
+		_, err := parseTlvs(b)
+		assert.NotNil(t, err, "%x", b)
+	}
+}
@@ -0,0 +1,18 @@
+package yubikey
+
+import "context"
+
+type touchCallbackKey struct{}
+
+// WithTouchCallback returns a copy of ctx that makes YubiKey operations call f when the YubiKey
+// starts waiting for its button to be touched.
+func WithTouchCallback(ctx context.Context, f func()) context.Context {
+	return context.WithValue(ctx, touchCallbackKey{}, f)
+}
+
+func touchCallback(ctx context.Context) func() {
+	if f, ok := ctx.Value(touchCallbackKey{}).(func()); ok && f != nil {
+		return f
+	}
+	return func() {}
+}
@@ -0,0 +1,62 @@
+package yubikey
+
+import (
+	"io"
+	"time"
+
+	"github.com/google/gousb"
+)
+
+// transport exchanges HID feature reports with a YubiKey.
+type transport interface {
+	io.Closer
+	getFeatureReport(fr []byte) error
+	setFeatureReport(fr []byte) error
+	product() (string, error)
+}
+
+// usbTransport sends feature reports to a YubiKey using USB control transfers.
+type usbTransport struct {
+	dev *gousb.Device
+}
+
+func (t *usbTransport) getFeatureReport(fr []byte) error {
+	_, err := t.dev.Control(usbTypeClass|usbRecipInterface|usbEndpointIn, hidGetReport, reportTypeFeature<<8, 0, fr)
+	return err
+}
+
+func (t *usbTransport) setFeatureReport(fr []byte) error {
+	_, err := t.dev.Control(usbTypeClass|usbRecipInterface|usbEndpointOut, hidSetReport, reportTypeFeature<<8, 0, fr)
+	return err
+}
+
+func (t *usbTransport) product() (string, error) {
+	return t.dev.Product()
+}
+
+func (t *usbTransport) Close() error {
+	return t.dev.Close()
+}
+
+// pollTiming controls how often the YubiKey's status is polled while waiting for it.
+type pollTiming struct {
+	initialDelay time.Duration
+	interval     time.Duration
+	maxWait      time.Duration
+}
+
+var defaultPollTiming = pollTiming{
+	initialDelay: 10 * time.Millisecond,
+	interval:     500 * time.Millisecond,
+	maxWait:      20 * time.Second,
+}
+
+const (
+	usbTypeClass      uint8 = 0x01 << 5
+	usbRecipInterface uint8 = 0x01
+	usbEndpointIn     uint8 = 0x80
+	usbEndpointOut    uint8 = 0x00
+
+	hidGetReport uint8 = 0x01
+	hidSetReport uint8 = 0x09
+)
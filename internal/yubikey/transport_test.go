@@ -0,0 +1,109 @@
+package yubikey
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// traceTransport replays a recorded sequence of feature report transfers. Each line of a trace
+// is either "get HEX", the report the YubiKey returned, or "set HEX", the report that was sent to
+// it. Anything after a '#' is a comment.
+type traceTransport struct {
+	name   string
+	events []traceEvent
+	next   int
+}
+
+type traceEvent struct {
+	line int
+	op   string
+	data []byte
+}
+
+func loadTrace(t *testing.T, name string) *traceTransport {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", "traces", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	tr := traceTransport{name: name}
+	s := bufio.NewScanner(f)
+	for line := 1; s.Scan(); line++ {
+		text, _, _ := strings.Cut(s.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 || fields[0] != "get" && fields[0] != "set" {
+			t.Fatalf("%s:%d: expected \"get HEX\" or \"set HEX\"", name, line)
+		}
+		data, err := hex.DecodeString(fields[1])
+		if err != nil || len(data) != featureReportSize {
+			t.Fatalf("%s:%d: expected %d bytes of hex", name, line, featureReportSize)
+		}
+		tr.events = append(tr.events, traceEvent{line, fields[0], data})
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &tr
+}
+
+func (tr *traceTransport) getFeatureReport(fr []byte) error {
+	e, err := tr.expect("get", nil)
+	if err != nil {
+		return err
+	}
+	copy(fr, e.data)
+	return nil
+}
+
+func (tr *traceTransport) setFeatureReport(fr []byte) error {
+	_, err := tr.expect("set", fr)
+	return err
+}
+
+func (tr *traceTransport) expect(op string, data []byte) (traceEvent, error) {
+	if tr.next >= len(tr.events) {
+		return traceEvent{}, fmt.Errorf("%s: unexpected %s %x after end of trace", tr.name, op, data)
+	}
+	e := tr.events[tr.next]
+	tr.next++
+	if e.op != op {
+		return traceEvent{}, fmt.Errorf("%s:%d: expected %s, got %s %x", tr.name, e.line, e.op, op, data)
+	}
+	if op == "set" && !bytes.Equal(e.data, data) {
+		return traceEvent{}, fmt.Errorf("%s:%d: expected set %x, got set %x", tr.name, e.line, e.data, data)
+	}
+	return e, nil
+}
+
+// done reports whether the whole trace was replayed.
+func (tr *traceTransport) done() bool {
+	return tr.next == len(tr.events)
+}
+
+func (tr *traceTransport) product() (string, error) {
+	return "YubiKey OTP+FIDO+CCID", nil
+}
+
+func (tr *traceTransport) Close() error {
+	return nil
+}
+
+// newTracedYubiKey returns a YubiKey that replays the named trace without waiting between polls.
+func newTracedYubiKey(t *testing.T, name string) (*usbHidYubiKey, *traceTransport) {
+	tr := loadTrace(t, name)
+	return &usbHidYubiKey{t: tr, timing: pollTiming{maxWait: time.Second, interval: time.Microsecond}}, tr
+}
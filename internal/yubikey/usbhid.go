@@ -2,6 +2,7 @@ package yubikey
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha1"
 	"encoding/binary"
 	"fmt"
@@ -14,7 +15,7 @@ import (
 func (y *usbHidYubiKey) Close() error {
 	var err1, err2 error
 
-	err1 = y.dev.Close()
+	err1 = y.t.Close()
 	if y.ctx != nil {
 		err2 = y.ctx.Close()
 	}
@@ -25,17 +26,22 @@ func (y *usbHidYubiKey) Close() error {
 	return err2
 }
 
-func (y *usbHidYubiKey) ChallengeResponseHmacSha1(slot int, challenge []byte) ([]byte, error) {
+func (y *usbHidYubiKey) ChallengeResponseHmacSha1(ctx context.Context, slot int, challenge []byte) ([]byte, error) {
 	if len(challenge) > slotDataSize {
 		return nil, fmt.Errorf("invalid challenge (expected maximum of %d bytes)", slotDataSize)
 	}
 
-	f := frame{payload: challenge, slot: slotChalRespHmacSlot1}
-	if slot == 2 {
+	f := frame{payload: challenge}
+	switch slot {
+	case 1:
+		f.slot = slotChalRespHmacSlot1
+	case 2:
 		f.slot = slotChalRespHmacSlot2
+	default:
+		return nil, fmt.Errorf("invalid slot (expected 1 or 2)")
 	}
 
-	data, err := y.writeAndRead(f, sha1.Size)
+	data, err := y.writeAndRead(ctx, f, sha1.Size)
 	if err != nil {
 		return nil, fmt.Errorf("writeAndRead: %w", err)
 	}
@@ -43,9 +49,75 @@ func (y *usbHidYubiKey) ChallengeResponseHmacSha1(slot int, challenge []byte) ([
 	return data, nil
 }
 
+// ProgramHmacSha1 writes an HMAC-SHA1 challenge-response configuration to the given slot,
+// overwriting whatever was previously configured there.
+func (y *usbHidYubiKey) ProgramHmacSha1(ctx context.Context, slot int, cfg HmacSha1Config) error {
+	c, err := newHmacSha1SlotConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("newHmacSha1SlotConfig: %w", err)
+	}
+	return y.writeConfig(ctx, slot, c, cfg.AccessCode)
+}
+
+// ProgramYubicoOtp writes a Yubico OTP configuration to the given slot, overwriting whatever was
+// previously configured there.
+func (y *usbHidYubiKey) ProgramYubicoOtp(ctx context.Context, slot int, cfg YubicoOtpConfig) error {
+	c, err := newYubicoOtpSlotConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("newYubicoOtpSlotConfig: %w", err)
+	}
+	return y.writeConfig(ctx, slot, c, cfg.AccessCode)
+}
+
+func (y *usbHidYubiKey) writeConfig(ctx context.Context, slot int, c slotConfig, accessCode []byte) error {
+	f := frame{}
+	switch slot {
+	case 1:
+		f.slot = slotConfig1
+	case 2:
+		f.slot = slotConfig2
+	default:
+		return fmt.Errorf("invalid slot (expected 1 or 2)")
+	}
+
+	var err error
+	f.payload, err = slotConfigCommand(c, accessCode)
+	if err != nil {
+		return fmt.Errorf("slotConfigCommand: %w", err)
+	}
+
+	err = y.reset()
+	if err != nil {
+		return fmt.Errorf("reset: %w", err)
+	}
+
+	before, err := y.waitUntilReadyForSlotWrite(ctx)
+	if err != nil {
+		return fmt.Errorf("waitUntilReadyForSlotWrite: %w", err)
+	}
+
+	err = y.writeFrame(ctx, f)
+	if err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	after, err := y.waitUntilReadyForSlotWrite(ctx)
+	if err != nil {
+		return fmt.Errorf("waitUntilReadyForSlotWrite: %w", err)
+	}
+
+	// The YubiKey increments its programming sequence number when a configuration is accepted.
+	// It's silently ignored if, for example, the access code is wrong.
+	if after[statusProgrammingSequence] == before[statusProgrammingSequence] {
+		return fmt.Errorf("configuration was not applied (is the access code correct?)")
+	}
+
+	return nil
+}
+
 func (y *usbHidYubiKey) Serial() (string, error) {
 	f := frame{slot: slotDeviceSerial}
-	data, err := y.writeAndRead(f, 4)
+	data, err := y.writeAndRead(context.Background(), f, 4)
 	if err != nil {
 		return "", fmt.Errorf("writeAndRead: %w", err)
 	}
@@ -56,7 +128,7 @@ func (y *usbHidYubiKey) Serial() (string, error) {
 }
 
 func (y *usbHidYubiKey) Type() (string, error) {
-	return y.dev.Product()
+	return y.t.product()
 }
 
 func (y *usbHidYubiKey) Version() (string, error) {
@@ -87,7 +159,8 @@ func findYubikeys(ctx *gousb.Context) ([]*usbHidYubiKey, error) {
 
 	yks := make([]*usbHidYubiKey, len(devs))
 	for i, d := range devs {
-		yks[i] = &usbHidYubiKey{dev: d}
+		d.ControlTimeout = 2 * time.Second
+		yks[i] = &usbHidYubiKey{t: &usbTransport{dev: d}, timing: defaultPollTiming}
 	}
 
 	return yks, nil
@@ -114,23 +187,28 @@ func (y *usbHidYubiKey) info() (Info, error) {
 	}, nil
 }
 
-func (y *usbHidYubiKey) waitUntilReadyForSlotWrite() ([]byte, error) {
-	return y.waitForStatus(func(status statusFlags) bool {
+func (y *usbHidYubiKey) waitUntilReadyForSlotWrite(ctx context.Context) ([]byte, error) {
+	return y.waitForStatus(ctx, func(status statusFlags) bool {
 		return !status.SlotWrite()
 	})
 }
 
-func (y *usbHidYubiKey) waitUntilResponseIsPending() ([]byte, error) {
-	return y.waitForStatus(func(status statusFlags) bool {
+func (y *usbHidYubiKey) waitUntilResponseIsPending(ctx context.Context) ([]byte, error) {
+	return y.waitForStatus(ctx, func(status statusFlags) bool {
 		return status.ResponsePending()
 	})
 }
 
-func (y *usbHidYubiKey) waitForStatus(check func(status statusFlags) bool) ([]byte, error) {
-	maxDelay := 20 * time.Second
-	perLoopDelay := 500 * time.Millisecond
-	time.Sleep(10 * time.Millisecond)
-	for remaining := maxDelay; remaining > 0; remaining -= perLoopDelay {
+// waitForStatus polls the YubiKey until check returns true. If the YubiKey starts waiting for
+// its button to be touched, the context's touch callback is called once.
+func (y *usbHidYubiKey) waitForStatus(ctx context.Context, check func(status statusFlags) bool) ([]byte, error) {
+	err := sleep(ctx, y.timing.initialDelay)
+	if err != nil {
+		return nil, err
+	}
+
+	touching := false
+	for remaining := y.timing.maxWait; remaining > 0; remaining -= y.timing.interval {
 		data, status, err := y.readFeatureReport()
 		if err != nil {
 			return nil, err
@@ -138,35 +216,57 @@ func (y *usbHidYubiKey) waitForStatus(check func(status statusFlags) bool) ([]by
 
 		wait := status.TimeoutWait()
 		if wait > 0 {
-			remaining = time.Duration(wait)*time.Second - perLoopDelay
+			remaining = time.Duration(wait)*time.Second - y.timing.interval
+			if !touching {
+				touching = true
+				touchCallback(ctx)()
+			}
 		}
 
 		if check(status) {
 			return data, nil
 		}
-		time.Sleep(perLoopDelay)
+
+		err = sleep(ctx, y.timing.interval)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return nil, fmt.Errorf("timed out")
 }
 
-func (y *usbHidYubiKey) writeAndRead(f frame, responseLen int) ([]byte, error) {
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func (y *usbHidYubiKey) writeAndRead(ctx context.Context, f frame, responseLen int) ([]byte, error) {
 	err := y.reset()
 	if err != nil {
 		return nil, fmt.Errorf("reset: %w", err)
 	}
 
-	_, err = y.waitUntilReadyForSlotWrite()
+	_, err = y.waitUntilReadyForSlotWrite(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("waitUntilReadyForSlotWrite: %w", err)
 	}
 
-	err = y.writeFrame(f)
+	err = y.writeFrame(ctx, f)
 	if err != nil {
 		return nil, fmt.Errorf("write: %w", err)
 	}
 
-	prefix, err := y.waitUntilResponseIsPending()
+	prefix, err := y.waitUntilResponseIsPending(ctx)
 	if err != nil {
+		// Abort the pending operation so that the YubiKey doesn't keep waiting for a touch.
+		_ = y.reset()
 		return nil, fmt.Errorf("waitUntilResponseIsPending: %w", err)
 	}
 
@@ -205,7 +305,7 @@ func (y *usbHidYubiKey) readFrame() ([]byte, error) {
 
 func (y *usbHidYubiKey) readFeatureReport() ([]byte, statusFlags, error) {
 	fr := make([]byte, featureReportSize)
-	_, err := y.dev.Control(usbTypeClass|usbRecipInterface|usbEndpointIn, hidGetReport, reportTypeFeature<<8, 0, fr)
+	err := y.t.getFeatureReport(fr)
 	return fr[:featureReportSize-1], statusFlags(fr[featureReportSize-1]), err
 }
 
@@ -213,13 +313,13 @@ func (y *usbHidYubiKey) reset() error {
 	return y.writeFeatureReport([8]byte{0, 0, 0, 0, 0, 0, 0, slotDummyReport})
 }
 
-func (y *usbHidYubiKey) writeFrame(f frame) error {
+func (y *usbHidYubiKey) writeFrame(ctx context.Context, f frame) error {
 	data := f.toBytes()
 	seq := byte(0)
 	count := byte((len(data) + 6) / 7)
 
 	for i := 0; i < len(data); i += featureReportSize - 1 {
-		_, err := y.waitUntilReadyForSlotWrite()
+		_, err := y.waitUntilReadyForSlotWrite(ctx)
 		if err != nil {
 			return err
 		}
@@ -242,27 +342,17 @@ func (y *usbHidYubiKey) writeFrame(f frame) error {
 }
 
 func (y *usbHidYubiKey) writeFeatureReport(fr [featureReportSize]byte) error {
-	_, err := y.dev.Control(usbTypeClass|usbRecipInterface|usbEndpointOut, hidSetReport, reportTypeFeature<<8, 0, fr[:])
-	return err
+	return y.t.setFeatureReport(fr[:])
 }
 
 type usbHidYubiKey struct {
-	ctx *gousb.Context
-	dev *gousb.Device
+	ctx    *gousb.Context
+	t      transport
+	timing pollTiming
 }
 
 const yubicoVid = 0x1050
 
-const (
-	usbTypeClass      uint8 = 0x01 << 5
-	usbRecipInterface uint8 = 0x01
-	usbEndpointIn     uint8 = 0x80
-	usbEndpointOut    uint8 = 0x00
-
-	hidGetReport uint8 = 0x01
-	hidSetReport uint8 = 0x09
-)
-
 const (
 	reportTypeFeature uint16 = 0x03
 
@@ -277,7 +367,12 @@ const (
 	slotWriteFlag           = 0x80
 )
 
+// statusProgrammingSequence is the offset of the programming sequence number in a status report.
+const statusProgrammingSequence = 4
+
 const (
+	slotConfig1           byte = 0x01
+	slotConfig2           byte = 0x03
 	slotDeviceSerial      byte = 0x10
 	slotChalRespHmacSlot1 byte = 0x30
 	slotChalRespHmacSlot2 byte = 0x38
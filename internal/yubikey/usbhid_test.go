@@ -1,6 +1,7 @@
 package yubikey
 
 import (
+	"context"
 	"crypto/rand"
 	"testing"
 
@@ -90,12 +91,105 @@ func TestUsbHidYubikey_ChallengeResponseHmacSha1(t *testing.T) {
 	challenge := make([]byte, 64)
 	_, err = rand.Read(challenge)
 	assert.Nil(t, err)
-	expected, err := ykEm.ChallengeResponseHmacSha1(info.Hmacsha1.Slot, challenge)
+	expected, err := ykEm.ChallengeResponseHmacSha1(context.Background(), info.Hmacsha1.Slot, challenge)
 	assert.Nil(t, err)
 
-	actual, err := yk.ChallengeResponseHmacSha1(info.Hmacsha1.Slot, challenge)
+	actual, err := yk.ChallengeResponseHmacSha1(context.Background(), info.Hmacsha1.Slot, challenge)
 
 	assert.Nil(t, err)
 	assert.Len(t, actual, 20)
 	assert.Equal(t, expected, actual)
 }
+
+func TestUsbHidYubiKey_traces(t *testing.T) {
+	secret := testutil.UnHex("601598b189be7abaf383e5cb15f0429caf20da6a")
+	em, _ := NewEmulator(map[int][]byte{2: secret})
+	expectedResponse, _ := em.ChallengeResponseHmacSha1(context.Background(), 2, []byte("hunter2"))
+
+	testCases := []struct {
+		trace     string
+		run       func(yk YubiKey) (any, error)
+		expected  any
+		expectErr bool
+	}{
+		{"serial.trace", func(yk YubiKey) (any, error) { return yk.Serial() }, "1234567", false},
+		{"version.trace", func(yk YubiKey) (any, error) { return yk.Version() }, "4.1.6", false},
+		{"hmac-sha1.trace", func(yk YubiKey) (any, error) {
+			return yk.ChallengeResponseHmacSha1(context.Background(), 2, []byte("hunter2"))
+		}, expectedResponse, false},
+		{"hmac-sha1-touch.trace", func(yk YubiKey) (any, error) {
+			return yk.ChallengeResponseHmacSha1(context.Background(), 2, []byte("hunter2"))
+		}, expectedResponse, false},
+		{"program.trace", func(yk YubiKey) (any, error) {
+			return nil, yk.ProgramHmacSha1(context.Background(), 2, HmacSha1Config{Secret: secret, RequireTouch: true})
+		}, nil, false},
+		{"program-rejected.trace", func(yk YubiKey) (any, error) {
+			return nil, yk.ProgramHmacSha1(context.Background(), 2, HmacSha1Config{Secret: secret, RequireTouch: true})
+		}, nil, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.trace, func(t *testing.T) {
+			yk, tr := newTracedYubiKey(t, tc.trace)
+
+			actual, err := tc.run(yk)
+
+			if tc.expectErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, tc.expected, actual)
+			}
+			assert.True(t, tr.done(), "trace was not fully replayed")
+		})
+	}
+}
+
+func TestUsbHidYubiKey_traceMismatch(t *testing.T) {
+	yk, _ := newTracedYubiKey(t, "hmac-sha1.trace")
+
+	_, err := yk.ChallengeResponseHmacSha1(context.Background(), 2, []byte("hunter3"))
+	assert.NotNil(t, err)
+
+	_, err = yk.ChallengeResponseHmacSha1(context.Background(), 3, []byte("hunter2"))
+	assert.NotNil(t, err)
+}
+
+func TestUsbHidYubiKey_timeout(t *testing.T) {
+	yk, _ := newTracedYubiKey(t, "program.trace")
+	yk.timing.maxWait = 0
+
+	err := yk.ProgramHmacSha1(context.Background(), 2, HmacSha1Config{Secret: testutil.UnHex("601598b189be7abaf383e5cb15f0429caf20da6a"), RequireTouch: true})
+	assert.ErrorContains(t, err, "timed out")
+}
+
+func TestUsbHidYubiKey_Type_trace(t *testing.T) {
+	yk, _ := newTracedYubiKey(t, "version.trace")
+
+	typ, err := yk.Type()
+	assert.Nil(t, err)
+	assert.Equal(t, "YubiKey OTP+FIDO+CCID", typ)
+}
+
+func TestUsbHidYubiKey_touchCallback(t *testing.T) {
+	yk, tr := newTracedYubiKey(t, "hmac-sha1-touch.trace")
+	touched := 0
+	ctx := WithTouchCallback(context.Background(), func() { touched++ })
+
+	_, err := yk.ChallengeResponseHmacSha1(ctx, 2, []byte("hunter2"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, touched)
+	assert.True(t, tr.done())
+}
+
+func TestUsbHidYubiKey_cancelled(t *testing.T) {
+	yk, tr := newTracedYubiKey(t, "hmac-sha1-touch-cancelled.trace")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = WithTouchCallback(ctx, cancel)
+
+	_, err := yk.ChallengeResponseHmacSha1(ctx, 2, []byte("hunter2"))
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.True(t, tr.done(), "expected the pending challenge to be aborted")
+}
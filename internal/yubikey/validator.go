@@ -0,0 +1,211 @@
+package yubikey
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrYubicoOtpInvalid is returned when an OTP is malformed, from an unknown key, or doesn't
+	// decrypt correctly.
+	ErrYubicoOtpInvalid = errors.New("invalid Yubico OTP")
+	// ErrYubicoOtpReplayed is returned when an OTP's counters are not greater than those of the
+	// last OTP accepted from the same key.
+	ErrYubicoOtpReplayed = errors.New("Yubico OTP has already been used")
+)
+
+// YubicoOtpKey is a YubiKey whose OTPs can be validated. PublicId is in modhex.
+type YubicoOtpKey struct {
+	PublicId  string
+	PrivateId []byte
+	AesKey    []byte
+}
+
+// YubicoOtpCounter is the position of an OTP in a YubiKey's sequence of OTPs.
+type YubicoOtpCounter struct {
+	UsageCounter   uint16 `json:"usageCounter"`
+	SessionCounter uint8  `json:"sessionCounter"`
+}
+
+func (c YubicoOtpCounter) after(o YubicoOtpCounter) bool {
+	if c.UsageCounter != o.UsageCounter {
+		return c.UsageCounter > o.UsageCounter
+	}
+	return c.SessionCounter > o.SessionCounter
+}
+
+// YubicoOtpCounterStore remembers the counter of the last OTP accepted from each YubiKey, keyed by
+// public ID.
+type YubicoOtpCounterStore interface {
+	Counter(publicId string) (YubicoOtpCounter, bool, error)
+	SetCounter(publicId string, c YubicoOtpCounter) error
+}
+
+// YubicoOtpValidator validates Yubico OTPs offline, without YubiCloud.
+type YubicoOtpValidator struct {
+	mu       sync.Mutex
+	keys     map[string]YubicoOtpKey
+	counters YubicoOtpCounterStore
+}
+
+// NewYubicoOtpValidator returns a validator that accepts OTPs from keys, using counters to reject
+// replayed OTPs.
+func NewYubicoOtpValidator(keys []YubicoOtpKey, counters YubicoOtpCounterStore) (*YubicoOtpValidator, error) {
+	v := YubicoOtpValidator{keys: make(map[string]YubicoOtpKey, len(keys)), counters: counters}
+	for _, k := range keys {
+		publicId := strings.ToLower(k.PublicId)
+		if _, err := ModhexDecode(publicId); err != nil || publicId == "" {
+			return nil, fmt.Errorf("invalid public ID %q", k.PublicId)
+		}
+		if len(k.PrivateId) != uidSize || len(k.AesKey) != keySize {
+			return nil, fmt.Errorf("invalid private ID or AES key for %s", publicId)
+		}
+		v.keys[publicId] = k
+	}
+	return &v, nil
+}
+
+// Validate checks an OTP and records its counter, so the same OTP (or an older one) is rejected
+// the next time. It returns the OTP's public ID and decrypted token.
+func (v *YubicoOtpValidator) Validate(otp string) (string, YubicoOtpToken, error) {
+	otp = strings.ToLower(strings.TrimSpace(otp))
+	if len(otp) <= 2*yubicoOtpTokenSize {
+		return "", YubicoOtpToken{}, ErrYubicoOtpInvalid
+	}
+	publicId := otp[:len(otp)-2*yubicoOtpTokenSize]
+	k, found := v.keys[publicId]
+	if !found {
+		return "", YubicoOtpToken{}, ErrYubicoOtpInvalid
+	}
+
+	_, token, err := DecodeYubicoOtp(otp, k.AesKey)
+	if err != nil || subtle.ConstantTimeCompare(token.PrivateId, k.PrivateId) != 1 {
+		return "", YubicoOtpToken{}, ErrYubicoOtpInvalid
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	counter := YubicoOtpCounter{UsageCounter: token.UsageCounter, SessionCounter: token.SessionCounter}
+	last, found, err := v.counters.Counter(publicId)
+	if err != nil {
+		return "", YubicoOtpToken{}, fmt.Errorf("counters.Counter: %w", err)
+	}
+	if found && !counter.after(last) {
+		return "", YubicoOtpToken{}, ErrYubicoOtpReplayed
+	}
+	err = v.counters.SetCounter(publicId, counter)
+	if err != nil {
+		return "", YubicoOtpToken{}, fmt.Errorf("counters.SetCounter: %w", err)
+	}
+
+	return publicId, token, nil
+}
+
+// NewMemoryCounterStore returns a counter store that forgets everything when the process exits.
+func NewMemoryCounterStore() YubicoOtpCounterStore {
+	return &memoryCounterStore{counters: make(map[string]YubicoOtpCounter)}
+}
+
+type memoryCounterStore struct {
+	mu       sync.Mutex
+	counters map[string]YubicoOtpCounter
+}
+
+func (s *memoryCounterStore) Counter(publicId string) (YubicoOtpCounter, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, found := s.counters[publicId]
+	return c, found, nil
+}
+
+func (s *memoryCounterStore) SetCounter(publicId string, c YubicoOtpCounter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters[publicId] = c
+	return nil
+}
+
+// OpenFileCounterStore returns a counter store that keeps counters in a JSON file, which is
+// created if it doesn't exist. The file is rewritten atomically each time a counter changes.
+func OpenFileCounterStore(path string) (YubicoOtpCounterStore, error) {
+	s := fileCounterStore{path: path, counters: make(map[string]YubicoOtpCounter)}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		err = json.Unmarshal(data, &s.counters)
+		if err != nil {
+			return nil, fmt.Errorf("json.Unmarshal: %w", err)
+		}
+	}
+
+	return &s, nil
+}
+
+type fileCounterStore struct {
+	mu       sync.Mutex
+	path     string
+	counters map[string]YubicoOtpCounter
+}
+
+func (s *fileCounterStore) Counter(publicId string) (YubicoOtpCounter, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, found := s.counters[publicId]
+	return c, found, nil
+}
+
+func (s *fileCounterStore) SetCounter(publicId string, c YubicoOtpCounter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, found := s.counters[publicId]
+	s.counters[publicId] = c
+	err := s.save()
+	if err != nil {
+		if found {
+			s.counters[publicId] = prev
+		} else {
+			delete(s.counters, publicId)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *fileCounterStore) save() error {
+	data, err := json.MarshalIndent(s.counters, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.MarshalIndent: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("os.CreateTemp: %w", err)
+	}
+	_, err = f.Write(append(data, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("write %s: %w", s.path, err)
+	}
+	return nil
+}
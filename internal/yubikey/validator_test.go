@@ -0,0 +1,103 @@
+package yubikey
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/internal/testutil"
+)
+
+func TestYubicoOtpValidator(t *testing.T) {
+	key := YubicoOtpKey{
+		PublicId:  "lbldlelflglh",
+		PrivateId: testutil.UnHex("8792ebfe26cc"),
+		AesKey:    testutil.UnHex("ecde18dbe76fbd0c33330f1c354871db"),
+	}
+	yk := newOtpEmulator(t, key)
+	v, err := NewYubicoOtpValidator([]YubicoOtpKey{key}, NewMemoryCounterStore())
+	assert.Nil(t, err)
+
+	first, _ := yk.YubicoOtp(1)
+	second, _ := yk.YubicoOtp(1)
+
+	publicId, token, err := v.Validate(first)
+	assert.Nil(t, err)
+	assert.Equal(t, "lbldlelflglh", publicId)
+	assert.Equal(t, uint8(0), token.SessionCounter)
+
+	_, _, err = v.Validate(second)
+	assert.Nil(t, err)
+
+	_, _, err = v.Validate(second)
+	assert.ErrorIs(t, err, ErrYubicoOtpReplayed)
+	_, _, err = v.Validate(first)
+	assert.ErrorIs(t, err, ErrYubicoOtpReplayed)
+
+	// An OTP from the same key with a different private ID.
+	wrongId, _ := EncodeYubicoOtp(testutil.UnHex("a1a2a3a4a5a6"), key.AesKey, YubicoOtpToken{PrivateId: make([]byte, 6), UsageCounter: 10})
+	_, _, err = v.Validate(wrongId)
+	assert.ErrorIs(t, err, ErrYubicoOtpInvalid)
+
+	// Change the last character of an OTP, to a different one than it already is.
+	tampered := first[:43] + "c"
+	if first[43] == 'c' {
+		tampered = first[:43] + "b"
+	}
+	for _, otp := range []string{"", "lbldlelflglh", "cccccccccccc" + first[12:], tampered} {
+		_, _, err = v.Validate(otp)
+		assert.ErrorIs(t, err, ErrYubicoOtpInvalid, otp)
+	}
+
+	_, err = NewYubicoOtpValidator([]YubicoOtpKey{{PublicId: "xyz", PrivateId: key.PrivateId, AesKey: key.AesKey}}, NewMemoryCounterStore())
+	assert.NotNil(t, err)
+	_, err = NewYubicoOtpValidator([]YubicoOtpKey{{PublicId: key.PublicId, AesKey: key.AesKey}}, NewMemoryCounterStore())
+	assert.NotNil(t, err)
+}
+
+func TestOpenFileCounterStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counters.json")
+	key := YubicoOtpKey{
+		PublicId:  "lbldlelflglh",
+		PrivateId: testutil.UnHex("8792ebfe26cc"),
+		AesKey:    testutil.UnHex("ecde18dbe76fbd0c33330f1c354871db"),
+	}
+	yk := newOtpEmulator(t, key)
+	otp, _ := yk.YubicoOtp(1)
+
+	store, err := OpenFileCounterStore(path)
+	assert.Nil(t, err)
+	v, _ := NewYubicoOtpValidator([]YubicoOtpKey{key}, store)
+	_, _, err = v.Validate(otp)
+	assert.Nil(t, err)
+
+	// The counter survives reopening the store.
+	store, err = OpenFileCounterStore(path)
+	assert.Nil(t, err)
+	c, found, err := store.Counter("lbldlelflglh")
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, YubicoOtpCounter{UsageCounter: 1, SessionCounter: 0}, c)
+	v, _ = NewYubicoOtpValidator([]YubicoOtpKey{key}, store)
+	_, _, err = v.Validate(otp)
+	assert.ErrorIs(t, err, ErrYubicoOtpReplayed)
+
+	_ = os.WriteFile(path, []byte("not json"), 0600)
+	_, err = OpenFileCounterStore(path)
+	assert.NotNil(t, err)
+}
+
+func newOtpEmulator(t *testing.T, key YubicoOtpKey) YubicoOtpGenerator {
+	t.Helper()
+
+	yk, _ := NewEmulator(nil)
+	publicId, _ := ModhexDecode(key.PublicId)
+	err := yk.ProgramYubicoOtp(context.Background(), 1, YubicoOtpConfig{PublicId: publicId, PrivateId: key.PrivateId, AesKey: key.AesKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return yk.(YubicoOtpGenerator)
+}
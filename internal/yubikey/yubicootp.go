@@ -0,0 +1,157 @@
+package yubikey
+
+import (
+	"crypto/aes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// YubicoOtpConfig describes a Yubico OTP configuration for a YubiKey slot.
+//
+// PublicId is sent in the clear at the start of each OTP and is at most 16 bytes, usually 6.
+// PrivateId must be exactly 6 bytes and AesKey exactly 16 bytes. AccessCode and NewAccessCode work
+// the same as in HmacSha1Config.
+type YubicoOtpConfig struct {
+	PublicId      []byte
+	PrivateId     []byte
+	AesKey        []byte
+	AccessCode    []byte
+	NewAccessCode []byte
+}
+
+// YubicoOtpToken is the decrypted part of a Yubico OTP.
+//
+// UsageCounter is incremented each time the YubiKey is plugged in, and SessionCounter each time
+// an OTP is generated while it stays plugged in. Timestamp is a 24-bit counter that runs at 8 Hz
+// from when the YubiKey was plugged in.
+type YubicoOtpToken struct {
+	PrivateId      []byte
+	UsageCounter   uint16
+	Timestamp      uint32
+	SessionCounter uint8
+	Random         uint16
+}
+
+// EncodeYubicoOtp returns the OTP for token, in modhex, as a YubiKey would type it.
+func EncodeYubicoOtp(publicId, aesKey []byte, token YubicoOtpToken) (string, error) {
+	if len(token.PrivateId) != uidSize {
+		return "", fmt.Errorf("expected private ID to be exactly %d bytes", uidSize)
+	}
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		return "", fmt.Errorf("aes.NewCipher: %w", err)
+	}
+
+	b := make([]byte, yubicoOtpTokenSize)
+	copy(b, token.PrivateId)
+	binary.LittleEndian.PutUint16(b[6:], token.UsageCounter)
+	b[8] = byte(token.Timestamp)
+	b[9] = byte(token.Timestamp >> 8)
+	b[10] = byte(token.Timestamp >> 16)
+	b[11] = token.SessionCounter
+	binary.LittleEndian.PutUint16(b[12:], token.Random)
+	binary.LittleEndian.PutUint16(b[14:], ^crc16(b[:14]))
+
+	block.Encrypt(b, b)
+
+	return ModhexEncode(publicId) + ModhexEncode(b), nil
+}
+
+// DecodeYubicoOtp decrypts a Yubico OTP with aesKey and returns its public ID and token. It fails
+// if the token's checksum is wrong, which usually means the key is wrong.
+func DecodeYubicoOtp(otp string, aesKey []byte) ([]byte, YubicoOtpToken, error) {
+	otp = strings.ToLower(strings.TrimSpace(otp))
+	if len(otp) < 2*yubicoOtpTokenSize || len(otp) > 2*(yubicoOtpTokenSize+fixedSize) {
+		return nil, YubicoOtpToken{}, fmt.Errorf("invalid OTP length")
+	}
+	split := len(otp) - 2*yubicoOtpTokenSize
+	publicId, err := ModhexDecode(otp[:split])
+	if err != nil {
+		return nil, YubicoOtpToken{}, err
+	}
+	b, err := ModhexDecode(otp[split:])
+	if err != nil {
+		return nil, YubicoOtpToken{}, err
+	}
+
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		return nil, YubicoOtpToken{}, fmt.Errorf("aes.NewCipher: %w", err)
+	}
+	block.Decrypt(b, b)
+	if !verifyCrc(b) {
+		return nil, YubicoOtpToken{}, fmt.Errorf("invalid checksum on OTP")
+	}
+
+	return publicId, YubicoOtpToken{
+		PrivateId:      b[:6],
+		UsageCounter:   binary.LittleEndian.Uint16(b[6:]),
+		Timestamp:      uint32(b[8]) | uint32(b[9])<<8 | uint32(b[10])<<16,
+		SessionCounter: b[11],
+		Random:         binary.LittleEndian.Uint16(b[12:]),
+	}, nil
+}
+
+// ModhexEncode encodes b using modhex, the hex alphabet YubiKeys use so that OTPs type the same
+// way on any keyboard layout.
+func ModhexEncode(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		sb.WriteByte(modhexAlphabet[c>>4])
+		sb.WriteByte(modhexAlphabet[c&0x0f])
+	}
+	return sb.String()
+}
+
+// ModhexDecode decodes a modhex string.
+func ModhexDecode(s string) ([]byte, error) {
+	if len(s)%2 != 0 {
+		return nil, fmt.Errorf("invalid modhex: odd length")
+	}
+	b := make([]byte, len(s)/2)
+	for i := 0; i < len(s); i++ {
+		n := strings.IndexByte(modhexAlphabet, s[i]|0x20)
+		if n < 0 {
+			return nil, fmt.Errorf("invalid modhex character %q", s[i])
+		}
+		b[i/2] = b[i/2]<<4 | byte(n)
+	}
+	return b, nil
+}
+
+func newYubicoOtpSlotConfig(cfg YubicoOtpConfig) (slotConfig, error) {
+	if len(cfg.PublicId) > fixedSize {
+		return slotConfig{}, fmt.Errorf("expected public ID to be no more than %d bytes", fixedSize)
+	}
+	if len(cfg.PrivateId) != uidSize {
+		return slotConfig{}, fmt.Errorf("expected private ID to be exactly %d bytes", uidSize)
+	}
+	if len(cfg.AesKey) != keySize {
+		return slotConfig{}, fmt.Errorf("expected AES key to be exactly %d bytes", keySize)
+	}
+	if len(cfg.NewAccessCode) > accessCodeSize {
+		return slotConfig{}, fmt.Errorf("expected access code to be no more than %d bytes", accessCodeSize)
+	}
+
+	c := slotConfig{
+		fixedSize: byte(len(cfg.PublicId)),
+		extFlags:  extFlagSerialApiVisible | extFlagAllowUpdate,
+		tktFlags:  tktFlagAppendCr,
+	}
+	copy(c.fixed[:], cfg.PublicId)
+	copy(c.uid[:], cfg.PrivateId)
+	copy(c.key[:], cfg.AesKey)
+	copy(c.accCode[:], cfg.NewAccessCode)
+
+	return c, nil
+}
+
+func (c slotConfig) isYubicoOtp() bool {
+	return c.tktFlags&tktFlagChalResp == 0 && c.cfgFlags&cfgFlagStaticTicket == 0
+}
+
+const (
+	yubicoOtpTokenSize = 16
+	modhexAlphabet     = "cbdefghijklnrtuv"
+)
@@ -0,0 +1,141 @@
+package yubikey
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/internal/testutil"
+)
+
+// goldenOtpToken is the token part of an OTP generated by EncodeYubicoOtp in TestEncodeYubicoOtp.
+var goldenOtpToken = "vkgtbrchkliictihvcuerbfnvcruubdv"
+
+func TestModhex(t *testing.T) {
+	testCases := []struct {
+		modhex string
+		data   []byte
+	}{
+		{"", []byte{}},
+		{"cbdefghijklnrtuv", testutil.UnHex("0123456789abcdef")},
+		{"vvccccccccccccvv", testutil.UnHex("ff000000000000ff")},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.modhex, ModhexEncode(tc.data))
+		actual, err := ModhexDecode(tc.modhex)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.data, actual)
+	}
+
+	actual, err := ModhexDecode("CBDE")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x01, 0x23}, actual)
+
+	_, err = ModhexDecode("cbd")
+	assert.NotNil(t, err)
+	_, err = ModhexDecode("cbda")
+	assert.NotNil(t, err)
+}
+
+func TestEncodeYubicoOtp(t *testing.T) {
+	aesKey := testutil.UnHex("ecde18dbe76fbd0c33330f1c354871db")
+	token := YubicoOtpToken{
+		PrivateId:      testutil.UnHex("8792ebfe26cc"),
+		UsageCounter:   19,
+		Timestamp:      0x8c0a,
+		SessionCounter: 0,
+		Random:         0x7f3c,
+	}
+
+	otp, err := EncodeYubicoOtp(testutil.UnHex("a1a2a3a4a5a6"), aesKey, token)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "lbldlelflglh"+goldenOtpToken, otp)
+
+	publicId, decoded, err := DecodeYubicoOtp(otp, aesKey)
+	assert.Nil(t, err)
+	assert.Equal(t, testutil.UnHex("a1a2a3a4a5a6"), publicId)
+	assert.Equal(t, token, decoded)
+
+	_, err = EncodeYubicoOtp(nil, aesKey, YubicoOtpToken{})
+	assert.NotNil(t, err)
+}
+
+func TestDecodeYubicoOtp(t *testing.T) {
+	aesKey := testutil.UnHex("ecde18dbe76fbd0c33330f1c354871db")
+
+	publicId, token, err := DecodeYubicoOtp(strings.ToUpper(goldenOtpToken)+"\n", aesKey)
+	assert.Nil(t, err)
+	assert.Empty(t, publicId)
+	assert.Equal(t, uint16(19), token.UsageCounter)
+
+	testCases := []struct {
+		otp    string
+		aesKey []byte
+	}{
+		{goldenOtpToken, testutil.UnHex("ecde18dbe76fbd0c33330f1c354871dc")},
+		{goldenOtpToken[:31], aesKey},
+		{"x" + goldenOtpToken[1:], aesKey},
+		{strings.Repeat("c", 34) + goldenOtpToken, aesKey},
+		{goldenOtpToken, aesKey[:15]},
+	}
+	for _, tc := range testCases {
+		_, _, err := DecodeYubicoOtp(tc.otp, tc.aesKey)
+		assert.NotNil(t, err, tc.otp)
+	}
+}
+
+func TestEmulatedYubiKey_YubicoOtp(t *testing.T) {
+	yk, _ := NewEmulator(nil)
+	cfg := YubicoOtpConfig{
+		PublicId:  testutil.UnHex("a1a2a3a4a5a6"),
+		PrivateId: testutil.UnHex("8792ebfe26cc"),
+		AesKey:    testutil.UnHex("ecde18dbe76fbd0c33330f1c354871db"),
+	}
+	gen := yk.(YubicoOtpGenerator)
+
+	_, err := gen.YubicoOtp(1)
+	assert.NotNil(t, err)
+
+	err = yk.ProgramYubicoOtp(context.Background(), 1, cfg)
+	assert.Nil(t, err)
+
+	for i := 0; i < 300; i++ {
+		otp, err := gen.YubicoOtp(1)
+		assert.Nil(t, err)
+		assert.Len(t, otp, 44)
+
+		_, token, err := DecodeYubicoOtp(otp, cfg.AesKey)
+		assert.Nil(t, err)
+		assert.Equal(t, cfg.PrivateId, token.PrivateId)
+		assert.Equal(t, uint16(1+i/256), token.UsageCounter)
+		assert.Equal(t, uint8(i%256), token.SessionCounter)
+	}
+
+	err = yk.ProgramYubicoOtp(context.Background(), 1, YubicoOtpConfig{PrivateId: cfg.PrivateId, AesKey: cfg.AesKey[:8]})
+	assert.NotNil(t, err)
+}
+
+func TestNewYubicoOtpSlotConfig(t *testing.T) {
+	c, err := newYubicoOtpSlotConfig(YubicoOtpConfig{
+		PublicId:  testutil.UnHex("a1a2a3a4a5a6"),
+		PrivateId: testutil.UnHex("8792ebfe26cc"),
+		AesKey:    testutil.UnHex("ecde18dbe76fbd0c33330f1c354871db"),
+	})
+	assert.Nil(t, err)
+
+	b := c.toBytes()
+	assert.Equal(t, testutil.UnHex("a1a2a3a4a5a600000000000000000000"), b[:16])
+	assert.Equal(t, testutil.UnHex("8792ebfe26cc"), b[16:22])
+	assert.Equal(t, testutil.UnHex("ecde18dbe76fbd0c33330f1c354871db"), b[22:38])
+	assert.Equal(t, []byte{6, 0x24, 0x20, 0x00}, b[44:48])
+	assert.True(t, c.isYubicoOtp())
+	assert.False(t, c.isHmacSha1())
+
+	_, err = newYubicoOtpSlotConfig(YubicoOtpConfig{PublicId: make([]byte, 17), PrivateId: make([]byte, 6), AesKey: make([]byte, 16)})
+	assert.NotNil(t, err)
+	_, err = newYubicoOtpSlotConfig(YubicoOtpConfig{PrivateId: make([]byte, 5), AesKey: make([]byte, 16)})
+	assert.NotNil(t, err)
+}
@@ -1,23 +1,35 @@
 package yubikey
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"time"
 
 	"github.com/google/gousb"
 )
 
 const MaxChallengeLen = slotDataSize
 
+// ErrYubikeyNotFound is returned when no connected YubiKey matches the one requested.
+var ErrYubikeyNotFound = errors.New("no YubiKey found")
+
 type YubiKey interface {
 	io.Closer
-	ChallengeResponseHmacSha1(slot int, challenge []byte) ([]byte, error)
+	ChallengeResponseHmacSha1(ctx context.Context, slot int, challenge []byte) ([]byte, error)
+	ProgramHmacSha1(ctx context.Context, slot int, cfg HmacSha1Config) error
+	ProgramYubicoOtp(ctx context.Context, slot int, cfg YubicoOtpConfig) error
 	Serial() (string, error)
 	Type() (string, error)
 	Version() (string, error)
 }
 
+// YubicoOtpGenerator is implemented by YubiKeys whose Yubico OTPs can be read directly, rather
+// than typed as keystrokes. Only the emulator implements it.
+type YubicoOtpGenerator interface {
+	YubicoOtp(slot int) (string, error)
+}
+
 type Info struct {
 	Serial  string
 	Type    string
@@ -58,12 +70,11 @@ func Open() (YubiKey, error) {
 
 	if len(yks) == 0 {
 		_ = ctx.Close()
-		return nil, fmt.Errorf("no Yubikeys found")
+		return nil, ErrYubikeyNotFound
 	}
 
 	yk := yks[0]
 	yk.ctx = ctx
-	yk.dev.ControlTimeout = 2 * time.Second
 
 	for i, yk := range yks {
 		if i == 0 {
@@ -95,12 +106,11 @@ func OpenBySerialNumber(serial string) (YubiKey, error) {
 
 	if index == -1 {
 		_ = ctx.Close()
-		return nil, fmt.Errorf("no Yubikey with serial number %s", serial)
+		return nil, fmt.Errorf("%w (no Yubikey with serial number %s)", ErrYubikeyNotFound, serial)
 	}
 
 	yk := yks[index]
 	yk.ctx = ctx
-	yk.dev.ControlTimeout = 2 * time.Second
 
 	return yk, nil
 }
@@ -0,0 +1,81 @@
+package otp
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"notpass-go/pkg/vault"
+)
+
+var ErrNoKey = errors.New("entry does not contain a one-time password key")
+
+// KeyFromEntry returns the one-time password key stored in a vault entry.
+//
+// If the entry has a two-factor key field, it is used along with any TOTP parameters stored
+// alongside it. Otherwise, the note and then the remaining fields (in order by name) are searched
+// for an otpauth:// URI. If no key is found, KeyFromEntry returns ErrNoKey.
+func KeyFromEntry(e vault.Entry) (Key, error) {
+	if v := e.Get(vault.TwoFactorKeyField); v != nil {
+		return keyFromFields(e, v.AsString())
+	}
+
+	fields := e.Fields()
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		if name != vault.NoteField {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{vault.NoteField}, names...)
+
+	for _, name := range names {
+		if v := fields[name]; v != nil {
+			if uri := uriPattern.FindString(v.AsString()); uri != "" {
+				return ParseURI(uri)
+			}
+		}
+	}
+
+	return Key{}, ErrNoKey
+}
+
+func keyFromFields(e vault.Entry, secret string) (Key, error) {
+	var err error
+	k := Key{Type: TOTP}
+	k.Secret, err = DecodeSecret(secret)
+	if err != nil {
+		return Key{}, err
+	}
+
+	// Backends keep TOTP parameters they can't decode, such as an algorithm from a newer version
+	// of the format, as raw bytes. Such a key can't produce correct codes.
+	for _, f := range []string{vault.TotpAlgorithmField, vault.TotpDigitsField, vault.TotpPeriodField, vault.TotpStartTimeField} {
+		if v, ok := e.Get(f).(vault.Bytes); ok {
+			return Key{}, fmt.Errorf("unsupported %s: %s", f, v.AsString())
+		}
+	}
+
+	if v := e.Get(vault.TotpAlgorithmField); v != nil {
+		k.Algorithm = Algorithm(v.AsString())
+	}
+	if v := e.Get(vault.TotpDigitsField); v != nil {
+		k.Digits, err = strconv.Atoi(v.AsString())
+		if err != nil {
+			return Key{}, err
+		}
+	}
+	if v, ok := e.Get(vault.TotpPeriodField).(vault.Duration); ok {
+		k.Period = time.Duration(v)
+	}
+	if v, ok := e.Get(vault.TotpStartTimeField).(vault.Timestamp); ok {
+		k.T0 = time.Time(v)
+	}
+	return k, nil
+}
+
+var uriPattern = regexp.MustCompile(`otpauth://[^\s"'<>]+`)
@@ -0,0 +1,59 @@
+package otp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/pkg/sensitive"
+	"notpass-go/pkg/vault"
+)
+
+func TestKeyFromEntry(t *testing.T) {
+	e := vault.NewEntry().
+		With(vault.TwoFactorKeyField, sensitive.String("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")).
+		With(vault.TotpDigitsField, vault.String("8")).
+		With(vault.TotpPeriodField, vault.Duration(time.Minute)).
+		WithNote("otpauth://totp/alice?secret=HXDMVJECJJWSRB3HWIZR4IFUGFTMXBOZ")
+
+	k, err := KeyFromEntry(e)
+
+	assert.Nil(t, err)
+	assert.Equal(t, rfcSeedSha1, k.Secret)
+	assert.Equal(t, 8, k.Digits)
+	assert.Equal(t, time.Minute, k.Period)
+}
+
+func TestKeyFromEntry_undecodedParameters(t *testing.T) {
+	for _, f := range []string{vault.TotpAlgorithmField, vault.TotpDigitsField, vault.TotpPeriodField, vault.TotpStartTimeField} {
+		e := vault.NewEntry().
+			With(vault.TwoFactorKeyField, sensitive.String("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")).
+			With(f, vault.Bytes{0x01})
+
+		_, err := KeyFromEntry(e)
+
+		assert.NotNil(t, err, f)
+	}
+}
+
+func TestKeyFromEntry_uri(t *testing.T) {
+	testCases := []vault.Entry{
+		vault.NewEntry().WithNote("Recovery codes are in the safe.\n\notpauth://totp/alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ\n"),
+		vault.NewEntry().With("MFA", sensitive.String("otpauth://totp/alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")),
+		vault.NewEntry().WithUrl("otpauth://totp/alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"),
+	}
+
+	for _, e := range testCases {
+		k, err := KeyFromEntry(e)
+		assert.Nil(t, err)
+		assert.Equal(t, "alice", k.Account)
+		assert.Equal(t, rfcSeedSha1, k.Secret)
+	}
+}
+
+func TestKeyFromEntry_noKey(t *testing.T) {
+	_, err := KeyFromEntry(vault.NewEntry().WithName("foo").WithNote("nothing to see here"))
+
+	assert.ErrorIs(t, err, ErrNoKey)
+}
@@ -0,0 +1,146 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"time"
+)
+
+// Key contains the parameters needed to generate one-time passwords.
+//
+// Type is either TOTP or HOTP. Digits, Period, Algorithm, and T0 default to 6, 30 seconds, SHA1,
+// and the Unix epoch, respectively, if they are not set.
+type Key struct {
+	Type      Type
+	Secret    []byte
+	Algorithm Algorithm
+	Digits    int
+	Period    time.Duration
+	T0        time.Time
+	Counter   uint64
+	Issuer    string
+	Account   string
+}
+
+// Code returns the one-time password for time t and the time at which it stops being valid.
+//
+// For HOTP keys, Code returns the password for the current Counter and a zero time.
+func (k Key) Code(t time.Time) (string, time.Time, error) {
+	if k.Type == HOTP {
+		code, err := HOTPCode(k.Secret, k.Counter, k.digits(), k.algorithm())
+		return code, time.Time{}, err
+	}
+
+	counter, expires := k.step(t)
+	code, err := HOTPCode(k.Secret, counter, k.digits(), k.algorithm())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return code, expires, nil
+}
+
+func (k Key) step(t time.Time) (uint64, time.Time) {
+	// Work in whole seconds, since time.Duration can't represent the full range of time_t.
+	period := int64(k.period() / time.Second)
+	t0 := k.t0()
+	elapsed := t.Unix() - t0.Unix()
+	if elapsed < 0 {
+		return 0, t0.Add(time.Duration(period) * time.Second)
+	}
+	n := elapsed / period
+	return uint64(n), time.Unix(t0.Unix()+(n+1)*period, 0)
+}
+
+func (k Key) t0() time.Time {
+	if k.T0.IsZero() {
+		return time.Unix(0, 0)
+	}
+	return k.T0
+}
+
+func (k Key) algorithm() Algorithm {
+	if k.Algorithm == "" {
+		return SHA1
+	}
+	return k.Algorithm
+}
+
+func (k Key) digits() int {
+	if k.Digits == 0 {
+		return 6
+	}
+	return k.Digits
+}
+
+func (k Key) period() time.Duration {
+	if k.Period < time.Second {
+		return 30 * time.Second
+	}
+	return k.Period
+}
+
+// HOTPCode computes an HOTP value as described in RFC 4226, using the specified hash algorithm as
+// permitted by RFC 6238.
+func HOTPCode(secret []byte, counter uint64, digits int, alg Algorithm) (string, error) {
+	if digits < 1 || digits > 10 {
+		return "", fmt.Errorf("invalid number of digits: expected 1 to 10")
+	}
+	newHash, err := alg.hash()
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	h := hmac.New(newHash, secret)
+	h.Write(msg[:])
+	sum := h.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	binCode := uint64(binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff)
+
+	mod := uint64(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, binCode%mod), nil
+}
+
+// TOTPCode computes a TOTP value as described in RFC 6238.
+func TOTPCode(secret []byte, t time.Time, period time.Duration, digits int, alg Algorithm) (string, error) {
+	code, _, err := Key{Type: TOTP, Secret: secret, Period: period, Digits: digits, Algorithm: alg}.Code(t)
+	return code, err
+}
+
+type Type string
+
+const (
+	TOTP Type = "totp"
+	HOTP Type = "hotp"
+)
+
+type Algorithm string
+
+const (
+	SHA1   Algorithm = "SHA1"
+	SHA256 Algorithm = "SHA256"
+	SHA512 Algorithm = "SHA512"
+)
+
+func (a Algorithm) hash() (func() hash.Hash, error) {
+	switch a {
+	case SHA1:
+		return sha1.New, nil
+	case SHA256:
+		return sha256.New, nil
+	case SHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %s", a)
+	}
+}
@@ -0,0 +1,98 @@
+package otp
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	rfcSeedSha1   = []byte("12345678901234567890")
+	rfcSeedSha256 = []byte("12345678901234567890123456789012")
+	rfcSeedSha512 = []byte("1234567890123456789012345678901234567890123456789012345678901234")
+)
+
+// https://datatracker.ietf.org/doc/html/rfc4226#appendix-D
+func TestHOTPCode(t *testing.T) {
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for counter, code := range expected {
+		actual, err := HOTPCode(rfcSeedSha1, uint64(counter), 6, SHA1)
+		assert.Nil(t, err)
+		assert.Equal(t, code, actual)
+	}
+}
+
+func TestHOTPCode_errors(t *testing.T) {
+	_, err := HOTPCode(rfcSeedSha1, 0, 0, SHA1)
+	assert.NotNil(t, err)
+	_, err = HOTPCode(rfcSeedSha1, 0, 11, SHA1)
+	assert.NotNil(t, err)
+	_, err = HOTPCode(rfcSeedSha1, 0, 6, "MD5")
+	assert.NotNil(t, err)
+}
+
+// https://datatracker.ietf.org/doc/html/rfc6238#appendix-B
+func TestTOTPCode(t *testing.T) {
+	testCases := []struct {
+		t      int64
+		sha1   string
+		sha256 string
+		sha512 string
+	}{
+		{59, "94287082", "46119246", "90693936"},
+		{1111111109, "07081804", "68084774", "25091201"},
+		{1111111111, "14050471", "67062674", "99943326"},
+		{1234567890, "89005924", "91819424", "93441116"},
+		{2000000000, "69279037", "90698825", "38618901"},
+		{20000000000, "65353130", "77737706", "47863826"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", tc.t), func(t *testing.T) {
+			ts := time.Unix(tc.t, 0)
+
+			actual, err := TOTPCode(rfcSeedSha1, ts, 30*time.Second, 8, SHA1)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.sha1, actual)
+
+			actual, err = TOTPCode(rfcSeedSha256, ts, 30*time.Second, 8, SHA256)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.sha256, actual)
+
+			actual, err = TOTPCode(rfcSeedSha512, ts, 30*time.Second, 8, SHA512)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.sha512, actual)
+		})
+	}
+}
+
+func TestKey_Code(t *testing.T) {
+	k := Key{Type: TOTP, Secret: rfcSeedSha1}
+
+	code, expires, err := k.Code(time.Unix(59, 0))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "287082", code)
+	assert.Equal(t, time.Unix(60, 0), expires)
+
+	k.T0 = time.Unix(100, 0)
+	k.Period = time.Minute
+	code, expires, err = k.Code(time.Unix(170, 0))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "287082", code)
+	assert.Equal(t, time.Unix(220, 0), expires)
+}
+
+func TestKey_Code_hotp(t *testing.T) {
+	k := Key{Type: HOTP, Secret: rfcSeedSha1, Counter: 3}
+
+	code, expires, err := k.Code(time.Now())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "969429", code)
+	assert.True(t, expires.IsZero())
+}
@@ -0,0 +1,93 @@
+package otp
+
+import (
+	"encoding/base32"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseURI parses a key in the otpauth:// URI format used by authenticator apps.
+//
+// See https://github.com/google/google-authenticator/wiki/Key-Uri-Format
+func ParseURI(uri string) (Key, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return Key{}, fmt.Errorf("url.Parse: %w", err)
+	}
+	if u.Scheme != "otpauth" {
+		return Key{}, fmt.Errorf("invalid scheme: expected otpauth")
+	}
+
+	k := Key{Type: Type(strings.ToLower(u.Host))}
+	if k.Type != TOTP && k.Type != HOTP {
+		return Key{}, fmt.Errorf("invalid type: expected totp or hotp")
+	}
+
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, found := strings.Cut(label, ":"); found {
+		k.Issuer = strings.TrimSpace(issuer)
+		k.Account = strings.TrimSpace(account)
+	} else {
+		k.Account = label
+	}
+
+	q := u.Query()
+	k.Secret, err = DecodeSecret(q.Get("secret"))
+	if err != nil {
+		return Key{}, err
+	}
+	if issuer := q.Get("issuer"); issuer != "" {
+		k.Issuer = issuer
+	}
+	if alg := q.Get("algorithm"); alg != "" {
+		k.Algorithm = Algorithm(strings.ToUpper(alg))
+		if _, err := k.Algorithm.hash(); err != nil {
+			return Key{}, err
+		}
+	}
+	if digits := q.Get("digits"); digits != "" {
+		k.Digits, err = strconv.Atoi(digits)
+		if err != nil || k.Digits < 1 || k.Digits > 10 {
+			return Key{}, fmt.Errorf("invalid digits: %s", digits)
+		}
+	}
+	if period := q.Get("period"); period != "" {
+		seconds, err := strconv.Atoi(period)
+		if err != nil || seconds < 1 {
+			return Key{}, fmt.Errorf("invalid period: %s", period)
+		}
+		k.Period = time.Duration(seconds) * time.Second
+	}
+	if counter := q.Get("counter"); counter != "" {
+		k.Counter, err = strconv.ParseUint(counter, 10, 64)
+		if err != nil {
+			return Key{}, fmt.Errorf("invalid counter: %s", counter)
+		}
+	} else if k.Type == HOTP {
+		return Key{}, fmt.Errorf("missing counter")
+	}
+
+	return k, nil
+}
+
+// DecodeSecret decodes a base32-encoded secret, ignoring case, spaces, and padding.
+func DecodeSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+	s = strings.TrimRight(s, "=")
+	if s == "" {
+		return nil, fmt.Errorf("missing secret")
+	}
+	b, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret: %w", err)
+	}
+	return b, nil
+}
+
+// EncodeSecret encodes a secret in base32 without padding.
+func EncodeSecret(b []byte) string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)
+}
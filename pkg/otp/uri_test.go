@@ -0,0 +1,62 @@
+package otp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseURI(t *testing.T) {
+	k, err := ParseURI("otpauth://totp/ACME%20Co:john.doe@email.com?secret=HXDMVJECJJWSRB3HWIZR4IFUGFTMXBOZ&issuer=ACME%20Co&algorithm=SHA256&digits=8&period=60")
+
+	assert.Nil(t, err)
+	assert.Equal(t, TOTP, k.Type)
+	assert.Equal(t, "ACME Co", k.Issuer)
+	assert.Equal(t, "john.doe@email.com", k.Account)
+	assert.Equal(t, SHA256, k.Algorithm)
+	assert.Equal(t, 8, k.Digits)
+	assert.Equal(t, time.Minute, k.Period)
+	assert.Len(t, k.Secret, 20)
+
+	k, err = ParseURI("otpauth://hotp/alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&counter=3")
+
+	assert.Nil(t, err)
+	assert.Equal(t, HOTP, k.Type)
+	assert.Equal(t, "alice", k.Account)
+	assert.Equal(t, rfcSeedSha1, k.Secret)
+	assert.Equal(t, uint64(3), k.Counter)
+
+	k, err = ParseURI("otpauth://totp/alice?secret=gezdgnbvgy3tqojqgezdgnbvgy3tqojq")
+
+	assert.Nil(t, err)
+	assert.Equal(t, rfcSeedSha1, k.Secret)
+}
+
+func TestParseURI_errors(t *testing.T) {
+	testCases := []string{
+		"",
+		"https://example.com/",
+		"otpauth://motp/alice?secret=GEZDGNBVGY3TQOJQ",
+		"otpauth://totp/alice",
+		"otpauth://totp/alice?secret=not-base32!",
+		"otpauth://totp/alice?secret=GEZDGNBVGY3TQOJQ&algorithm=MD5",
+		"otpauth://totp/alice?secret=GEZDGNBVGY3TQOJQ&digits=0",
+		"otpauth://totp/alice?secret=GEZDGNBVGY3TQOJQ&period=-30",
+		"otpauth://hotp/alice?secret=GEZDGNBVGY3TQOJQ",
+		"otpauth://hotp/alice?secret=GEZDGNBVGY3TQOJQ&counter=x",
+	}
+
+	for _, tc := range testCases {
+		_, err := ParseURI(tc)
+		assert.NotNil(t, err, tc)
+	}
+}
+
+func TestEncodeSecret(t *testing.T) {
+	assert.Equal(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", EncodeSecret(rfcSeedSha1))
+
+	b, err := DecodeSecret("gezd gnbv gy3t qojq gezd gnbv gy3t qojq")
+	assert.Nil(t, err)
+	assert.Equal(t, rfcSeedSha1, b)
+}
@@ -0,0 +1,126 @@
+package random
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// EstimateEntropy estimates the entropy of an existing password, in bits, using the same model
+// that Choice, Hex, Digits, and Passphrase use to describe the values they generate: the base 2
+// logarithm of the number of equally likely values that an attacker would have to consider.
+//
+// The password is evaluated both as a string of characters drawn from the character classes it
+// contains and, if possible, as a passphrase of words from dictionary with an optional hex or
+// decimal suffix. The lower of the two estimates is returned.
+//
+// To estimate the entropy of many passwords with the same dictionary, use an EntropyEstimator.
+func EstimateEntropy(password string, dictionary []string) float64 {
+	return NewEntropyEstimator(dictionary).Estimate(password)
+}
+
+// EntropyEstimator estimates the entropy of passwords like EstimateEntropy, with the lowercase
+// words of its dictionary in a set that is built once.
+type EntropyEstimator struct {
+	dictionarySize int
+	words          map[string]bool
+}
+
+func NewEntropyEstimator(dictionary []string) EntropyEstimator {
+	words := make(map[string]bool, len(dictionary))
+	for _, w := range dictionary {
+		words[strings.ToLower(w)] = true
+	}
+	return EntropyEstimator{dictionarySize: len(dictionary), words: words}
+}
+
+// Estimate returns the estimated entropy of password, in bits.
+func (e EntropyEstimator) Estimate(password string) float64 {
+	entropy := characterEntropy(password)
+	if p, ok := e.passphraseEntropy(password); ok && p < entropy {
+		entropy = p
+	}
+	return entropy
+}
+
+func characterEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+
+	n := float64(len([]rune(s)))
+	if isDecimal(s) {
+		return n * math.Log2(10)
+	}
+	if isHex(s) {
+		return n * 4
+	}
+
+	var lower, upper, digit, symbol, other bool
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII && unicode.IsPrint(r):
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if symbol {
+		pool += 33
+	}
+	if other {
+		pool += 100
+	}
+	return n * math.Log2(float64(pool))
+}
+
+func (e EntropyEstimator) passphraseEntropy(s string) (float64, bool) {
+	if e.dictionarySize == 0 {
+		return 0, false
+	}
+
+	tokens := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(tokens) == 0 {
+		return 0, false
+	}
+
+	perWord := math.Log2(float64(e.dictionarySize))
+	entropy := 0.0
+	for i, t := range tokens {
+		if e.words[strings.ToLower(t)] {
+			entropy += perWord
+		} else if i == len(tokens)-1 && i > 0 && (isDecimal(t) || isHex(t)) {
+			entropy += characterEntropy(t)
+		} else {
+			return 0, false
+		}
+	}
+	return entropy, true
+}
+
+func isDecimal(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
+
+func isHex(s string) bool {
+	return strings.Trim(strings.ToLower(s), "0123456789abcdef") == ""
+}
@@ -0,0 +1,67 @@
+package random
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateEntropy(t *testing.T) {
+	dictionary := []string{
+		"one",
+		"two",
+		"three",
+		"four",
+	}
+	testCases := []struct {
+		password string
+		expected float64
+	}{
+		{"", 0},
+		{"1234", 4 * math.Log2(10)},
+		{"20ad9ca2", 8 * 4},
+		{"hunter", 6 * math.Log2(26)},
+		{"Hunter2", 7 * math.Log2(62)},
+		{"Hunter2!", 8 * math.Log2(95)},
+		{"one", 2},
+		{"one-two-three", 6},
+		{"one.two.FOUR", 6},
+		{"one-two-3e8f0", 4 + 20},
+		{"one-two-12345", 4 + 5*math.Log2(10)},
+		{"one-five", 8 * math.Log2(59)},
+		{"one-3e8f0-two", 13 * math.Log2(69)},
+	}
+
+	for _, tc := range testCases {
+		actual := EstimateEntropy(tc.password, dictionary)
+		assert.True(t, closeEnough(tc.expected, actual), "%s: expected %f, got %f", tc.password, tc.expected, actual)
+	}
+}
+
+func TestEstimateEntropy_generated(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		p, err := Passphrase(DefaultDictionary, 3, 5, 16, "-")
+		assert.Nil(t, err)
+		// A hex suffix that happens to contain only decimal digits is estimated more conservatively.
+		assert.LessOrEqual(t, EstimateEntropy(p.Value, DefaultDictionary), p.Entropy+0.0001, p.Value)
+	}
+}
+
+func TestEntropyEstimator(t *testing.T) {
+	dictionary := []string{"Correct", "horse", "battery", "staple"}
+	e := NewEntropyEstimator(dictionary)
+
+	for _, p := range []string{"correct-horse-battery-staple", "Staple horse 42", "hunter2", ""} {
+		assert.Equal(t, EstimateEntropy(p, dictionary), e.Estimate(p), p)
+	}
+	assert.Equal(t, 4.0, e.Estimate("correct-horse"))
+}
+
+func BenchmarkEntropyEstimator_Estimate(b *testing.B) {
+	e := NewEntropyEstimator(DefaultDictionary)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.Estimate("correct-horse-battery-staple")
+	}
+}
@@ -0,0 +1,18 @@
+package sensitive
+
+import "encoding/hex"
+
+// Bytes is binary data that must not be printed by accident. AsString returns it in hex.
+type Bytes []byte
+
+func (b Bytes) AsString() string {
+	return hex.EncodeToString(b)
+}
+
+func (b Bytes) String() string {
+	return Redacted
+}
+
+func (b Bytes) GoString() string {
+	return Redacted
+}
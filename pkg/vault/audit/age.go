@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"fmt"
+	"time"
+
+	"notpass-go/pkg/vault"
+)
+
+// AgePolicy controls which entries are reported by CheckPasswordAge.
+//
+// Entries that expire within ExpiryWarning of Now are reported as Expiring. Entries whose password
+// was last changed more than MaxAge before Now are reported as TooOld. A MaxAge of zero disables
+// the age check.
+type AgePolicy struct {
+	Now           time.Time
+	ExpiryWarning time.Duration
+	MaxAge        time.Duration
+}
+
+// CheckPasswordAge reports entries that have expired, will expire soon, or have passwords that
+// are older than the policy allows.
+func CheckPasswordAge(entries []vault.Entry, p AgePolicy) []Finding {
+	var findings []Finding
+	for _, e := range entries {
+		if expiry, ok := ExpiryTime(e); ok {
+			if !expiry.After(p.Now) {
+				findings = append(findings, finding(e, Expired,
+					fmt.Sprintf("expired %s", expiry.Format(vault.DefaultTimeFormat)), &expiry, nil))
+			} else if expiry.Sub(p.Now) <= p.ExpiryWarning {
+				findings = append(findings, finding(e, Expiring,
+					fmt.Sprintf("expires %s", expiry.Format(vault.DefaultTimeFormat)), &expiry, nil))
+			}
+		}
+
+		if changed, ok := PasswordChangedAt(e); ok && p.MaxAge > 0 {
+			if age := p.Now.Sub(changed); age > p.MaxAge {
+				findings = append(findings, finding(e, TooOld,
+					fmt.Sprintf("password is %d days old", age/(24*time.Hour)), nil, &changed))
+			}
+		}
+	}
+	return findings
+}
+
+// ExpiryTime returns the time at which an entry's password expires.
+//
+// If the entry has a passwordExpiryInterval, the password expires that long after it was last
+// changed, or at its passwordExpiryTime, whichever is earlier.
+func ExpiryTime(e vault.Entry) (time.Time, bool) {
+	expiry, found := timestamp(e, vault.PasswordExpiryTimeField)
+
+	interval, ok := e.Get(vault.PasswordExpiryIntervalField).(vault.Duration)
+	if ok && interval > 0 {
+		if changed, ok := PasswordChangedAt(e); ok {
+			t := changed.Add(time.Duration(interval))
+			if !found || t.Before(expiry) {
+				expiry, found = t, true
+			}
+		}
+	}
+
+	return expiry, found
+}
+
+// PasswordChangedAt returns the time an entry's password was last changed. Entries whose password
+// has never been changed are considered to have been changed when they were created.
+func PasswordChangedAt(e vault.Entry) (time.Time, bool) {
+	if t, ok := timestamp(e, vault.PasswordModificationTimeField); ok {
+		return t, true
+	}
+	return timestamp(e, vault.CreationTimeField)
+}
+
+func timestamp(e vault.Entry, field string) (time.Time, bool) {
+	ts, ok := e.Get(field).(vault.Timestamp)
+	return time.Time(ts), ok && !time.Time(ts).IsZero()
+}
+
+func finding(e vault.Entry, issue Issue, detail string, expiresAt, lastChanged *time.Time) Finding {
+	return Finding{
+		Id:          e.Id(),
+		Group:       e.Group(),
+		Name:        e.Name(),
+		Issue:       issue,
+		Detail:      detail,
+		ExpiresAt:   expiresAt,
+		LastChanged: lastChanged,
+	}
+}
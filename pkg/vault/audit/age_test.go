@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/pkg/vault"
+)
+
+var now = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func daysAgo(days int) vault.Timestamp {
+	return vault.Timestamp(now.AddDate(0, 0, -days))
+}
+
+func TestCheckPasswordAge(t *testing.T) {
+	entries := []vault.Entry{
+		vault.NewEntry().WithId("expired").With(vault.PasswordExpiryTimeField, daysAgo(1)),
+		vault.NewEntry().WithId("expiring").With(vault.PasswordExpiryTimeField, daysAgo(-10)),
+		vault.NewEntry().WithId("later").With(vault.PasswordExpiryTimeField, daysAgo(-60)),
+		vault.NewEntry().WithId("old").With(vault.PasswordModificationTimeField, daysAgo(200)),
+		vault.NewEntry().WithId("old-created").With(vault.CreationTimeField, daysAgo(365)),
+		vault.NewEntry().WithId("recent").
+			With(vault.CreationTimeField, daysAgo(365)).
+			With(vault.PasswordModificationTimeField, daysAgo(10)),
+		vault.NewEntry().WithId("interval").
+			With(vault.PasswordModificationTimeField, daysAgo(100)).
+			With(vault.PasswordExpiryIntervalField, vault.Duration(90*24*time.Hour)),
+		vault.NewEntry().WithId("undecodable-interval").
+			With(vault.PasswordModificationTimeField, daysAgo(100)).
+			With(vault.PasswordExpiryIntervalField, vault.Bytes{0x5a, 0x00}),
+		vault.NewEntry().WithId("nothing"),
+	}
+
+	findings := CheckPasswordAge(entries, AgePolicy{
+		Now:           now,
+		ExpiryWarning: 30 * 24 * time.Hour,
+		MaxAge:        180 * 24 * time.Hour,
+	})
+
+	var actual []string
+	for _, f := range findings {
+		actual = append(actual, f.Id+":"+string(f.Issue))
+	}
+	assert.Equal(t, []string{
+		"expired:expired",
+		"expiring:expiring",
+		"old:too-old",
+		"old-created:too-old",
+		"interval:expired",
+	}, actual)
+}
+
+func TestCheckPasswordAge_noMaxAge(t *testing.T) {
+	entries := []vault.Entry{
+		vault.NewEntry().WithId("old").With(vault.PasswordModificationTimeField, daysAgo(2000)),
+	}
+
+	assert.Empty(t, CheckPasswordAge(entries, AgePolicy{Now: now}))
+}
+
+func TestExpiryTime(t *testing.T) {
+	testCases := []struct {
+		name     string
+		e        vault.Entry
+		expected time.Time
+		found    bool
+	}{
+		{"none", vault.NewEntry(), time.Time{}, false},
+		{"expiry time",
+			vault.NewEntry().With(vault.PasswordExpiryTimeField, daysAgo(5)),
+			now.AddDate(0, 0, -5), true},
+		{"interval",
+			vault.NewEntry().
+				With(vault.PasswordModificationTimeField, daysAgo(5)).
+				With(vault.PasswordExpiryIntervalField, vault.Duration(24*time.Hour)),
+			now.AddDate(0, 0, -4), true},
+		{"interval from creation",
+			vault.NewEntry().
+				With(vault.CreationTimeField, daysAgo(5)).
+				With(vault.PasswordExpiryIntervalField, vault.Duration(24*time.Hour)),
+			now.AddDate(0, 0, -4), true},
+		{"interval earlier than expiry time",
+			vault.NewEntry().
+				With(vault.PasswordExpiryTimeField, daysAgo(-30)).
+				With(vault.PasswordModificationTimeField, daysAgo(5)).
+				With(vault.PasswordExpiryIntervalField, vault.Duration(24*time.Hour)),
+			now.AddDate(0, 0, -4), true},
+		{"expiry time earlier than interval",
+			vault.NewEntry().
+				With(vault.PasswordExpiryTimeField, daysAgo(30)).
+				With(vault.PasswordModificationTimeField, daysAgo(5)).
+				With(vault.PasswordExpiryIntervalField, vault.Duration(24*time.Hour)),
+			now.AddDate(0, 0, -30), true},
+		{"interval without timestamps",
+			vault.NewEntry().With(vault.PasswordExpiryIntervalField, vault.Duration(24*time.Hour)),
+			time.Time{}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, found := ExpiryTime(tc.e)
+			assert.Equal(t, tc.found, found)
+			assert.True(t, tc.expected.Equal(actual))
+		})
+	}
+}
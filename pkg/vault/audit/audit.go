@@ -0,0 +1,27 @@
+package audit
+
+import (
+	"time"
+)
+
+// Finding describes a problem with a single vault entry. Findings never contain secret values.
+type Finding struct {
+	Id          string     `json:"id"`
+	Group       string     `json:"group,omitempty"`
+	Name        string     `json:"name"`
+	Issue       Issue      `json:"issue"`
+	Detail      string     `json:"detail"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	LastChanged *time.Time `json:"lastChanged,omitempty"`
+}
+
+type Issue string
+
+const (
+	Expired  Issue = "expired"
+	Expiring Issue = "expiring"
+	TooOld   Issue = "too-old"
+	Reused   Issue = "reused"
+	Weak     Issue = "weak"
+	Breached Issue = "breached"
+)
@@ -0,0 +1,152 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"notpass-go/pkg/sensitive"
+)
+
+// BreachCorpus looks up passwords in a local copy of the Have I Been Pwned password list.
+//
+// The corpus must be the SHA-1 version of the list, ordered by hash, with one "HASH:COUNT" entry
+// per line. Lookups binary-search the file, so it never needs to be loaded into memory, and no
+// network access is required.
+type BreachCorpus struct {
+	r      io.ReaderAt
+	size   int64
+	closer io.Closer
+}
+
+// OpenBreachCorpus opens a corpus stored in a file. The caller must close it when finished.
+func OpenBreachCorpus(path string) (*BreachCorpus, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("os.Open: %w", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("f.Stat: %w", err)
+	}
+
+	c := NewBreachCorpus(f, fi.Size())
+	c.closer = f
+	return c, nil
+}
+
+// NewBreachCorpus returns a corpus that reads size bytes from r.
+func NewBreachCorpus(r io.ReaderAt, size int64) *BreachCorpus {
+	return &BreachCorpus{r: r, size: size}
+}
+
+func (c *BreachCorpus) Close() error {
+	if c.closer != nil {
+		return c.closer.Close()
+	}
+	return nil
+}
+
+// Count returns the number of times password appears in the corpus, or zero if it does not.
+func (c *BreachCorpus) Count(password sensitive.String) (int, error) {
+	h := sha1.Sum([]byte(password))
+	return c.find(strings.ToUpper(hex.EncodeToString(h[:])))
+}
+
+func (c *BreachCorpus) find(target string) (int, error) {
+	// Every line that starts before lo has a hash less than the target. Every line that starts at
+	// or after hi has a hash greater than the target.
+	lo, hi := int64(0), c.size
+	for hi-lo > linearSearchThreshold {
+		mid := lo + (hi-lo)/2
+		start, line, err := c.lineAfter(mid)
+		if err != nil {
+			return 0, err
+		}
+		if start >= hi {
+			break
+		}
+
+		hash, count, err := parseCorpusLine(line)
+		if err != nil {
+			return 0, fmt.Errorf("invalid line at offset %d: %w", start, err)
+		}
+		switch strings.Compare(hash, target) {
+		case 0:
+			return count, nil
+		case -1:
+			lo = start
+		default:
+			hi = start
+		}
+	}
+
+	s := bufio.NewScanner(io.NewSectionReader(c.r, lo, c.size-lo))
+	for s.Scan() {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+		hash, count, err := parseCorpusLine(s.Bytes())
+		if err != nil {
+			return 0, fmt.Errorf("invalid line: %w", err)
+		}
+		switch strings.Compare(hash, target) {
+		case 0:
+			return count, nil
+		case 1:
+			return 0, nil
+		}
+	}
+	return 0, s.Err()
+}
+
+// lineAfter returns the first line that starts after offset, along with its offset. If there is
+// no such line, lineAfter returns the size of the corpus.
+func (c *BreachCorpus) lineAfter(offset int64) (int64, []byte, error) {
+	buf := make([]byte, 2*maxCorpusLineLen)
+	n, err := c.r.ReadAt(buf, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, nil, fmt.Errorf("r.ReadAt: %w", err)
+	}
+	buf = buf[:n]
+
+	i := bytes.IndexByte(buf, '\n')
+	if i == -1 || offset+int64(i)+1 >= c.size {
+		return c.size, nil, nil
+	}
+	line := buf[i+1:]
+	if j := bytes.IndexByte(line, '\n'); j != -1 {
+		line = line[:j]
+	} else if n == 2*maxCorpusLineLen {
+		return 0, nil, fmt.Errorf("line at offset %d is too long", offset+int64(i)+1)
+	}
+	return offset + int64(i) + 1, line, nil
+}
+
+func parseCorpusLine(line []byte) (string, int, error) {
+	hash, count, found := strings.Cut(strings.TrimSpace(string(line)), ":")
+	if len(hash) != 2*sha1.Size {
+		return "", 0, fmt.Errorf("expected %d hex characters", 2*sha1.Size)
+	}
+	if !found {
+		return strings.ToUpper(hash), 1, nil
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return "", 0, fmt.Errorf("strconv.Atoi: %w", err)
+	}
+	return strings.ToUpper(hash), n, nil
+}
+
+const (
+	linearSearchThreshold = 4096
+	maxCorpusLineLen      = 128
+)
@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/pkg/sensitive"
+)
+
+const testCorpus = "testdata/pwned-passwords.txt"
+
+func TestBreachCorpus_Count(t *testing.T) {
+	c, err := OpenBreachCorpus(testCorpus)
+	assert.Nil(t, err)
+	defer func() { _ = c.Close() }()
+
+	testCases := []struct {
+		password string
+		expected int
+	}{
+		{"password", 9659365},
+		{"hunter2", 17043},
+		{"123456", 37359195},
+		{"correct horse battery staple", 379},
+
+		{"", 0},
+		{"hunter3", 0},
+		{"FBuvy7MVN=-k3n@qjs>WQEeL9", 0},
+	}
+
+	for _, tc := range testCases {
+		n, err := c.Count(sensitive.String(tc.password))
+		assert.Nil(t, err)
+		assert.Equal(t, tc.expected, n, tc.password)
+	}
+}
+
+func TestBreachCorpus_everyLine(t *testing.T) {
+	data, err := os.ReadFile(testCorpus)
+	assert.Nil(t, err)
+	c := NewBreachCorpus(bytes.NewReader(data), int64(len(data)))
+
+	for _, line := range bytes.Split(bytes.TrimSpace(data), []byte("\r\n")) {
+		hash, count, err := parseCorpusLine(line)
+		assert.Nil(t, err)
+
+		n, err := c.find(hash)
+		assert.Nil(t, err)
+		assert.Equal(t, count, n, hash)
+	}
+
+	n, err := c.find("0000000000000000000000000000000000000000")
+	assert.Nil(t, err)
+	assert.Zero(t, n)
+	n, err = c.find("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF")
+	assert.Nil(t, err)
+	assert.Zero(t, n)
+}
+
+func TestBreachCorpus_errors(t *testing.T) {
+	_, err := OpenBreachCorpus("testdata/nonexistent")
+	assert.NotNil(t, err)
+
+	data := []byte("not a hash\n")
+	c := NewBreachCorpus(bytes.NewReader(data), int64(len(data)))
+	_, err = c.Count("password")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"notpass-go/pkg/random"
+	"notpass-go/pkg/vault"
+)
+
+// The checks in this file need each entry's password, so they must be given fully-decrypted
+// entries (e.g., from ReadableVault.Get rather than ReadableVault.List). Entries without a
+// password are ignored.
+
+// CheckReusedPasswords reports entries that share a password with at least one other entry.
+func CheckReusedPasswords(entries []vault.Entry) []Finding {
+	byPassword := make(map[[sha256.Size]byte][]vault.Entry)
+	var order [][sha256.Size]byte
+	for _, e := range entries {
+		if e.Password() == "" {
+			continue
+		}
+		h := sha256.Sum256([]byte(e.Password()))
+		if _, found := byPassword[h]; !found {
+			order = append(order, h)
+		}
+		byPassword[h] = append(byPassword[h], e)
+	}
+
+	var findings []Finding
+	for _, h := range order {
+		shared := byPassword[h]
+		if len(shared) < 2 {
+			continue
+		}
+		for _, e := range shared {
+			findings = append(findings, finding(e, Reused,
+				fmt.Sprintf("password is shared with %d other entries", len(shared)-1), nil, nil))
+		}
+	}
+	return findings
+}
+
+// CheckWeakPasswords reports entries whose passwords have less than minEntropy bits of entropy,
+// as estimated by random.EstimateEntropy.
+func CheckWeakPasswords(entries []vault.Entry, minEntropy float64, dictionary []string) []Finding {
+	estimator := random.NewEntropyEstimator(dictionary)
+	var findings []Finding
+	for _, e := range entries {
+		if e.Password() == "" {
+			continue
+		}
+		if entropy := estimator.Estimate(string(e.Password())); entropy < minEntropy {
+			findings = append(findings, finding(e, Weak,
+				fmt.Sprintf("password has an estimated %0.1f bits of entropy", entropy), nil, nil))
+		}
+	}
+	return findings
+}
+
+// CheckBreachedPasswords reports entries whose passwords appear in corpus.
+func CheckBreachedPasswords(entries []vault.Entry, corpus *BreachCorpus) ([]Finding, error) {
+	var findings []Finding
+	for _, e := range entries {
+		if e.Password() == "" {
+			continue
+		}
+		n, err := corpus.Count(e.Password())
+		if err != nil {
+			return nil, fmt.Errorf("corpus.Count: %w", err)
+		}
+		if n > 0 {
+			findings = append(findings, finding(e, Breached,
+				fmt.Sprintf("password appears in breach corpus %d times", n), nil, nil))
+		}
+	}
+	return findings, nil
+}
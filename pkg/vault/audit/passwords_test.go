@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/pkg/random"
+	"notpass-go/pkg/vault"
+)
+
+func TestCheckReusedPasswords(t *testing.T) {
+	entries := []vault.Entry{
+		vault.NewEntry().WithId("1").WithPassword("hunter2"),
+		vault.NewEntry().WithId("2").WithPassword("correct horse battery staple"),
+		vault.NewEntry().WithId("3").WithPassword("hunter2"),
+		vault.NewEntry().WithId("4"),
+		vault.NewEntry().WithId("5"),
+		vault.NewEntry().WithId("6").WithPassword("hunter2"),
+	}
+
+	findings := CheckReusedPasswords(entries)
+
+	assert.Len(t, findings, 3)
+	for i, id := range []string{"1", "3", "6"} {
+		assert.Equal(t, id, findings[i].Id)
+		assert.Equal(t, Reused, findings[i].Issue)
+		assert.Equal(t, "password is shared with 2 other entries", findings[i].Detail)
+	}
+}
+
+func TestCheckWeakPasswords(t *testing.T) {
+	entries := []vault.Entry{
+		vault.NewEntry().WithId("1").WithPassword("hunter2"),
+		vault.NewEntry().WithId("2").WithPassword("FBuvy7MVN=-k3n@qjs>WQEeL9"),
+		vault.NewEntry().WithId("3").WithPassword("aback-abacus-abalone"),
+		vault.NewEntry().WithId("4"),
+	}
+
+	findings := CheckWeakPasswords(entries, 50, random.DefaultDictionary)
+
+	assert.Len(t, findings, 2)
+	assert.Equal(t, "1", findings[0].Id)
+	assert.Equal(t, "3", findings[1].Id)
+	assert.Equal(t, "password has an estimated 39.0 bits of entropy", findings[1].Detail)
+}
+
+func TestCheckBreachedPasswords(t *testing.T) {
+	c, _ := OpenBreachCorpus(testCorpus)
+	defer func() { _ = c.Close() }()
+	entries := []vault.Entry{
+		vault.NewEntry().WithId("1").WithPassword("hunter2"),
+		vault.NewEntry().WithId("2").WithPassword("FBuvy7MVN=-k3n@qjs>WQEeL9"),
+		vault.NewEntry().WithId("3"),
+	}
+
+	findings, err := CheckBreachedPasswords(entries, c)
+
+	assert.Nil(t, err)
+	assert.Len(t, findings, 1)
+	assert.Equal(t, "1", findings[0].Id)
+	assert.Equal(t, "password appears in breach corpus 17043 times", findings[0].Detail)
+}
+
+func TestFinding_noSecrets(t *testing.T) {
+	entries := []vault.Entry{
+		vault.NewEntry().WithId("1").WithName("foo").WithPassword("hunter2"),
+		vault.NewEntry().WithId("2").WithName("bar").WithPassword("hunter2"),
+	}
+
+	for _, f := range append(CheckReusedPasswords(entries), CheckWeakPasswords(entries, 128, nil)...) {
+		assert.NotContains(t, f.Detail, "hunter2")
+	}
+}
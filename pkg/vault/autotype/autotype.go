@@ -0,0 +1,206 @@
+package autotype
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"notpass-go/pkg/sensitive"
+	"notpass-go/pkg/vault"
+)
+
+// Kind is the kind of a Step.
+type Kind int
+
+const (
+	// Text types Step.Text.
+	Text Kind = iota
+	// Key presses the special key Step.Key, e.g. "Tab".
+	Key
+	// Delay sets the delay between keystrokes to Step.Duration.
+	Delay
+	// Wait pauses for Step.Duration.
+	Wait
+)
+
+var kindNames = []string{"text", "key", "delay", "wait"}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Step is one action in a keystroke script. Text is sensitive because it may contain a password.
+type Step struct {
+	Kind     Kind
+	Text     sensitive.String
+	Key      string
+	Duration time.Duration
+}
+
+// Special keys that scripts can press.
+const (
+	TabKey       = "Tab"
+	ShiftTabKey  = "Shift+Tab"
+	EnterKey     = "Enter"
+	BackspaceKey = "Backspace"
+	EscapeKey    = "Escape"
+	SpaceKey     = "Space"
+)
+
+// DefaultScript returns the script PasswordSafe uses for entries without an autotype field: the
+// username, a tab, the password and enter, or just the password and enter if there is no
+// username.
+func DefaultScript(e vault.Entry) string {
+	if e.Username() == "" {
+		return `\p\n`
+	}
+	return `\u\t\p\n`
+}
+
+// Expand converts an entry's autotype field, or its default script, into keystrokes.
+//
+// Scripts use PasswordSafe's autotype codes:
+//
+//	\u      username
+//	\p      password
+//	\g      group
+//	\i      name (title)
+//	\l      URL
+//	\m      email
+//	\o      note
+//	\t      Tab
+//	\s      Shift+Tab
+//	\n      Enter
+//	\r      Enter (a carriage return)
+//	\b      Backspace
+//	\\      a backslash
+//	\d###   set the delay between keystrokes to ### milliseconds
+//	\w###   wait ### milliseconds
+//	\W###   wait ### seconds
+//	\{KEY}  press KEY, one of the special keys defined by this package
+//
+// Any other character, including a backslash that doesn't start one of these codes, is typed as is,
+// like PasswordSafe does.
+func Expand(e vault.Entry) ([]Step, error) {
+	script := e.Autotype()
+	if script == "" {
+		script = DefaultScript(e)
+	}
+	return ExpandScript(e, script)
+}
+
+// ExpandScript converts script into keystrokes, using e's fields for its codes. See Expand.
+func ExpandScript(e vault.Entry, script string) ([]Step, error) {
+	var steps []Step
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			steps = append(steps, Step{Kind: Text, Text: sensitive.String(text.String())})
+			text.Reset()
+		}
+	}
+	key := func(k string) {
+		flush()
+		steps = append(steps, Step{Kind: Key, Key: k})
+	}
+
+	for i := 0; i < len(script); i++ {
+		if script[i] != '\\' {
+			text.WriteByte(script[i])
+			continue
+		}
+		i++
+		if i == len(script) {
+			text.WriteByte('\\')
+			break
+		}
+
+		switch c := script[i]; c {
+		case 'u':
+			text.WriteString(e.Username())
+		case 'p':
+			text.WriteString(e.Password().AsString())
+		case 'g':
+			text.WriteString(e.Group())
+		case 'i':
+			text.WriteString(e.Name())
+		case 'l':
+			text.WriteString(e.Url())
+		case 'm':
+			text.WriteString(e.Email())
+		case 'o':
+			text.WriteString(e.Note().AsString())
+		case '\\':
+			text.WriteByte('\\')
+		case 't':
+			key(TabKey)
+		case 's':
+			key(ShiftTabKey)
+		case 'n', 'r':
+			key(EnterKey)
+		case 'b':
+			key(BackspaceKey)
+		case 'd', 'w', 'W':
+			n, length := leadingDigits(script[i+1:], 3)
+			if length == 0 {
+				return nil, fmt.Errorf("expected a number after \\%c", c)
+			}
+			i += length
+			d := time.Duration(n) * time.Millisecond
+			kind := Wait
+			if c == 'd' {
+				kind = Delay
+			} else if c == 'W' {
+				d = time.Duration(n) * time.Second
+			}
+			flush()
+			steps = append(steps, Step{Kind: kind, Duration: d})
+		case '{':
+			end := strings.IndexByte(script[i:], '}')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated \\{")
+			}
+			name := script[i+1 : i+end]
+			k, ok := specialKeys[strings.ToLower(name)]
+			if !ok {
+				return nil, fmt.Errorf("unknown key: %q", name)
+			}
+			key(k)
+			i += end
+		default:
+			text.WriteByte('\\')
+			text.WriteByte(c)
+		}
+	}
+	flush()
+
+	return steps, nil
+}
+
+var specialKeys = map[string]string{
+	"tab":       TabKey,
+	"shift+tab": ShiftTabKey,
+	"enter":     EnterKey,
+	"backspace": BackspaceKey,
+	"escape":    EscapeKey,
+	"esc":       EscapeKey,
+	"space":     SpaceKey,
+}
+
+// leadingDigits parses up to max decimal digits from the start of s, and returns their value and
+// how many there were.
+func leadingDigits(s string, max int) (int, int) {
+	n := 0
+	for n < len(s) && n < max && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	v, _ := strconv.Atoi(s[:n])
+	return v, n
+}
@@ -0,0 +1,71 @@
+package autotype
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/pkg/vault"
+)
+
+func TestExpand(t *testing.T) {
+	// Entry.With modifies the entry it's called on, so each test case needs its own.
+	e := func() vault.Entry {
+		return vault.NewEntry().
+			WithName("Bank").
+			WithUsername("luke").
+			WithPassword("hunter2").
+			WithUrl("https://bank.example.com/").
+			WithGroup("Finance/Banks").
+			WithEmail("luke@example.com")
+	}
+
+	testCases := []struct {
+		name     string
+		entry    vault.Entry
+		expected []Step
+	}{
+		{"default", e(), []Step{
+			{Kind: Text, Text: "luke"}, {Kind: Key, Key: TabKey}, {Kind: Text, Text: "hunter2"}, {Kind: Key, Key: EnterKey},
+		}},
+		{"default without username", e().WithUsername(""), []Step{
+			{Kind: Text, Text: "hunter2"}, {Kind: Key, Key: EnterKey},
+		}},
+		{"custom", e().WithAutotype(`\u\n\w500\p\n`), []Step{
+			{Kind: Text, Text: "luke"}, {Kind: Key, Key: EnterKey}, {Kind: Wait, Duration: 500 * time.Millisecond},
+			{Kind: Text, Text: "hunter2"}, {Kind: Key, Key: EnterKey},
+		}},
+		{"text and fields", e().WithAutotype(`user: \u\\\i \l`), []Step{
+			{Kind: Text, Text: `user: luke\Bank https://bank.example.com/`},
+		}},
+		{"group, email and carriage return", e().WithAutotype(`\g \m\r`), []Step{
+			{Kind: Text, Text: "Finance/Banks luke@example.com"}, {Kind: Key, Key: EnterKey},
+		}},
+		{"unknown codes", e().WithAutotype(`\x\u\`), []Step{
+			{Kind: Text, Text: `\xluke\`},
+		}},
+		{"delays", e().WithAutotype(`\d50\W2x\d1234`), []Step{
+			{Kind: Delay, Duration: 50 * time.Millisecond}, {Kind: Wait, Duration: 2 * time.Second},
+			{Kind: Text, Text: "x"}, {Kind: Delay, Duration: 123 * time.Millisecond}, {Kind: Text, Text: "4"},
+		}},
+		{"special keys", e().WithAutotype(`\s\b\{Esc}\{space}`), []Step{
+			{Kind: Key, Key: ShiftTabKey}, {Kind: Key, Key: BackspaceKey}, {Kind: Key, Key: EscapeKey}, {Kind: Key, Key: SpaceKey},
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			steps, err := Expand(tc.entry)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, steps)
+		})
+	}
+}
+
+func TestExpandScript_errors(t *testing.T) {
+	for _, script := range []string{`\d`, `\wabc`, `\{Tab`, `\{Hyper}`} {
+		_, err := ExpandScript(vault.NewEntry(), script)
+		assert.NotNil(t, err, script)
+	}
+}
@@ -1,6 +1,10 @@
 package vault
 
-import "notpass-go/pkg/sensitive"
+import (
+	"fmt"
+
+	"notpass-go/pkg/sensitive"
+)
 
 // NewEntry returns an Entry with an empty set of fields.
 func NewEntry() Entry {
@@ -58,6 +62,15 @@ func (e Entry) WithGroup(group string) Entry {
 	return e.With(GroupField, String(group))
 }
 
+// GroupPath returns the value for the "group" field, split into its levels.
+func (e Entry) GroupPath() GroupPath {
+	return ParseGroupPath(e.Group())
+}
+
+func (e Entry) WithGroupPath(path GroupPath) Entry {
+	return e.WithGroup(path.String())
+}
+
 // Name returns the value for the "name" field.
 func (e Entry) Name() string {
 	return e.getAsString(NameField)
@@ -101,6 +114,88 @@ func (e Entry) WithUrl(url string) Entry {
 	return e.With(UrlField, String(url))
 }
 
+// Autotype returns the value for the "autotype" field, which describes the keystrokes that log in
+// to the entry's account.
+func (e Entry) Autotype() string {
+	return e.getAsString(AutotypeField)
+}
+
+func (e Entry) WithAutotype(autotype string) Entry {
+	return e.With(AutotypeField, String(autotype))
+}
+
+// RunCommand returns the value for the "runCommand" field, which is a command line that opens the
+// entry's account.
+func (e Entry) RunCommand() string {
+	return e.getAsString(RunCommandField)
+}
+
+func (e Entry) WithRunCommand(command string) Entry {
+	return e.With(RunCommandField, String(command))
+}
+
+func (e Entry) Email() string {
+	return e.getAsString(EmailField)
+}
+
+func (e Entry) WithEmail(email string) Entry {
+	return e.With(EmailField, String(email))
+}
+
+// Protected returns the value for the "protected" field. Protected entries must not be edited or
+// deleted. A value that isn't false, such as raw bytes a backend couldn't decode, counts as
+// protected, so that a damaged flag doesn't allow edits.
+func (e Entry) Protected() bool {
+	v := e.Get(ProtectedField)
+	return v != nil && v.AsString() != "false"
+}
+
+func (e Entry) WithProtected(protected bool) Entry {
+	return e.With(ProtectedField, Bool(protected))
+}
+
+// CheckEditable returns ErrProtectedEntry if the entry is protected.
+func (e Entry) CheckEditable() error {
+	if e.Protected() {
+		return fmt.Errorf("%w: %s", ErrProtectedEntry, e.Id())
+	}
+	return nil
+}
+
+func (e Entry) CreditCardNumber() sensitive.String {
+	return e.getAsSensitiveString(CreditCardNumberField)
+}
+
+func (e Entry) WithCreditCardNumber(number sensitive.String) Entry {
+	return e.With(CreditCardNumberField, number)
+}
+
+// CreditCardExpiry returns the value for the "creditCardExpiry" field, as written on the card,
+// e.g. "04/27".
+func (e Entry) CreditCardExpiry() sensitive.String {
+	return e.getAsSensitiveString(CreditCardExpiryField)
+}
+
+func (e Entry) WithCreditCardExpiry(expiry sensitive.String) Entry {
+	return e.With(CreditCardExpiryField, expiry)
+}
+
+func (e Entry) CreditCardCVV() sensitive.String {
+	return e.getAsSensitiveString(CreditCardCVVField)
+}
+
+func (e Entry) WithCreditCardCVV(cvv sensitive.String) Entry {
+	return e.With(CreditCardCVVField, cvv)
+}
+
+func (e Entry) CreditCardPIN() sensitive.String {
+	return e.getAsSensitiveString(CreditCardPINField)
+}
+
+func (e Entry) WithCreditCardPIN(pin sensitive.String) Entry {
+	return e.With(CreditCardPINField, pin)
+}
+
 func (e Entry) Get(field string) Value {
 	return e.fields[field]
 }
@@ -142,4 +237,47 @@ const (
 	PasswordField = "password"
 	UrlField      = "url"
 	UsernameField = "username"
+
+	CreationTimeField             = "creationTime"
+	LastAccessTimeField           = "lastAccessTime"
+	LastModificationTimeField     = "lastModificationTime"
+	PasswordExpiryIntervalField   = "passwordExpiryInterval"
+	PasswordExpiryTimeField       = "passwordExpiryTime"
+	PasswordModificationTimeField = "passwordModificationTime"
+
+	// PasswordHistoryField holds the entry's previous passwords, oldest first, each as a List of
+	// the time it was replaced and the password.
+	PasswordHistoryField    = "passwordHistory"
+	PasswordPolicyField     = "passwordPolicy"
+	PasswordPolicyNameField = "passwordPolicyName"
+	PasswordSymbolsField    = "ownSymbolsForPassword"
+
+	AutotypeField               = "autotype"
+	RunCommandField             = "runCommand"
+	DoubleClickActionField      = "doubleClickAction"
+	ShiftDoubleClickActionField = "shiftDoubleClickAction"
+	KeyboardShortcutField       = "entryKeyboardShortcut"
+
+	EmailField = "email"
+
+	// ProtectedField is true for entries that must not be edited or deleted.
+	ProtectedField = "protected"
+
+	// Payment card details and QR code text are secrets, like the password.
+	CreditCardNumberField = "creditCardNumber"
+	CreditCardExpiryField = "creditCardExpiry"
+	CreditCardCVVField    = "creditCardCVV"
+	CreditCardPINField    = "creditCardPIN"
+	QRCodeField           = "qrCode"
+
+	// AliasOfField holds the ID of the entry an alias takes its password from, and
+	// ShortcutOfField the ID of the entry a shortcut takes all of its fields from.
+	AliasOfField    = "aliasOf"
+	ShortcutOfField = "shortcutOf"
+
+	TwoFactorKeyField  = "twoFactorKey"
+	TotpAlgorithmField = "totpAlgorithm"
+	TotpDigitsField    = "totpDigits"
+	TotpPeriodField    = "totpPeriod"
+	TotpStartTimeField = "totpStartTime"
 )
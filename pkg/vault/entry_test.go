@@ -67,3 +67,16 @@ func TestEntry_WithoutSecrets(t *testing.T) {
 	assert.Zero(t, e1.Note())
 	assert.Zero(t, e1.getAsString("CustomSecret"))
 }
+
+func TestEntry_Protected(t *testing.T) {
+	assert.False(t, Entry{}.Protected())
+	assert.Nil(t, Entry{}.CheckEditable())
+	assert.Nil(t, Entry{}.WithProtected(false).CheckEditable())
+
+	e := Entry{}.WithId("123").WithProtected(true)
+	assert.True(t, e.Protected())
+	assert.ErrorIs(t, e.CheckEditable(), ErrProtectedEntry)
+	e = Entry{}.WithId("123").With(ProtectedField, Bytes{0x01, 0x00, 0x00})
+	assert.True(t, e.Protected())
+	assert.ErrorIs(t, e.CheckEditable(), ErrProtectedEntry)
+}
@@ -0,0 +1,25 @@
+package vault
+
+import (
+	"errors"
+)
+
+// Errors returned when a vault cannot be opened. Backends wrap these, so check for them with
+// errors.Is.
+var (
+	// ErrIncorrectPassword means the password (or YubiKey response) did not unlock the vault.
+	ErrIncorrectPassword = errors.New("incorrect password")
+
+	// ErrIntegrity means the vault was unlocked, but its contents failed authentication, which
+	// suggests that the file is corrupt or has been tampered with.
+	ErrIntegrity = errors.New("vault failed integrity check")
+
+	// ErrTruncated means the vault file ends before all of its expected contents.
+	ErrTruncated = errors.New("vault file is truncated")
+
+	// ErrUnsupportedFormat means the file is not a vault, or is in a format that is not supported.
+	ErrUnsupportedFormat = errors.New("unsupported vault format")
+)
+
+// ErrProtectedEntry is returned when trying to edit or delete a protected entry.
+var ErrProtectedEntry = errors.New("entry is protected")
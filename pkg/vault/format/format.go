@@ -0,0 +1,169 @@
+package format
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"notpass-go/pkg/sensitive"
+	"notpass-go/pkg/vault"
+)
+
+// Value renders v as text, the same way in every format. Secrets are replaced with
+// sensitive.Redacted unless reveal is true. Only the secret elements of a list are redacted, and
+// lists within lists are enclosed in brackets.
+func Value(v vault.Value, reveal bool) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case vault.List:
+		return joinValues(v, reveal)
+	case vault.SecretList:
+		return joinValues(v, reveal)
+	case vault.Secret:
+		if !reveal {
+			return sensitive.Redacted
+		}
+		return v.AsString()
+	default:
+		return v.AsString()
+	}
+}
+
+func joinValues(values []vault.Value, reveal bool) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = Value(v, reveal)
+		if vault.TypeOf(v) == vault.ListType || vault.TypeOf(v) == vault.SecretListType {
+			s[i] = "[" + s[i] + "]"
+		}
+	}
+	return strings.Join(s, ", ")
+}
+
+// jsonValue converts v into a value that encoding/json renders as the closest JSON type: booleans
+// and integers as themselves, lists as arrays, timestamps in RFC 3339 and everything else as in
+// Value.
+func jsonValue(v vault.Value, reveal bool) any {
+	switch v := v.(type) {
+	case vault.Bool:
+		return bool(v)
+	case vault.Int:
+		return int64(v)
+	case vault.Timestamp:
+		return time.Time(v).Format(time.RFC3339)
+	case vault.List:
+		return jsonValues(v, reveal)
+	case vault.SecretList:
+		return jsonValues(v, reveal)
+	default:
+		return Value(v, reveal)
+	}
+}
+
+func jsonValues(values []vault.Value, reveal bool) []any {
+	a := make([]any, len(values))
+	for i, v := range values {
+		a[i] = jsonValue(v, reveal)
+	}
+	return a
+}
+
+// Fields returns e's fields as values that encoding/json can encode. See JSON.
+func Fields(e vault.Entry, reveal bool) map[string]any {
+	fields := make(map[string]any)
+	for k, v := range e.Fields() {
+		fields[k] = jsonValue(v, reveal)
+	}
+	return fields
+}
+
+// JSON writes entries as an array of objects, one per entry, with a member per field. Booleans
+// and integers are JSON booleans and numbers, lists are arrays, timestamps are in RFC 3339 and
+// other values are strings as rendered by Value.
+func JSON(w io.Writer, entries []vault.Entry, reveal bool) error {
+	list := make([]map[string]any, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, Fields(e, reveal))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(list)
+}
+
+// CSV writes entries as comma-separated values with a header row of field names, in the order of
+// Columns. Values are rendered by Value.
+func CSV(w io.Writer, entries []vault.Entry, reveal bool) error {
+	cw := csv.NewWriter(w)
+	columns := Columns(entries)
+	_ = cw.Write(columns)
+	for _, e := range entries {
+		_ = cw.Write(row(e, columns, reveal))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Table writes entries as aligned columns, with a header row of field names in upper case, in the
+// order of Columns. Values are rendered by Value.
+func Table(w io.Writer, entries []vault.Entry, reveal bool) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	columns := Columns(entries)
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = strings.ToUpper(c)
+	}
+	_, _ = fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, e := range entries {
+		_, _ = fmt.Fprintln(tw, strings.Join(row(e, columns, reveal), "\t"))
+	}
+	return tw.Flush()
+}
+
+func row(e vault.Entry, columns []string, reveal bool) []string {
+	r := make([]string, len(columns))
+	for i, c := range columns {
+		r[i] = Value(e.Get(c), reveal)
+	}
+	return r
+}
+
+// leadingColumns are the fields that identify an entry, which come first in CSV and tables.
+var leadingColumns = []string{
+	vault.IdField,
+	vault.GroupField,
+	vault.NameField,
+	vault.UsernameField,
+	vault.UrlField,
+}
+
+// Columns returns the names of the fields that any of entries have: the fields that identify an
+// entry first, then the rest in alphabetical order.
+func Columns(entries []vault.Entry) []string {
+	present := make(map[string]bool)
+	for _, e := range entries {
+		for k := range e.Fields() {
+			present[k] = true
+		}
+	}
+
+	var columns []string
+	for _, c := range leadingColumns {
+		if present[c] {
+			columns = append(columns, c)
+			delete(present, c)
+		}
+	}
+	rest := make([]string, 0, len(present))
+	for k := range present {
+		rest = append(rest, k)
+	}
+	sort.Strings(rest)
+	return append(columns, rest...)
+}
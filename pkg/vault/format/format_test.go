@@ -0,0 +1,104 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/pkg/sensitive"
+	"notpass-go/pkg/vault"
+)
+
+func testEntry() vault.Entry {
+	changed := time.Date(2023, 3, 19, 14, 42, 19, 0, time.UTC)
+	return vault.NewEntry().
+		WithId("123").
+		WithName("Bank").
+		WithUsername("luke").
+		WithPassword("hunter2").
+		WithProtected(true).
+		With(vault.TotpDigitsField, vault.Int(6)).
+		With(vault.KeyboardShortcutField, vault.Bytes{0x41, 0x06}).
+		With(vault.PasswordExpiryIntervalField, vault.Duration(48*time.Hour)).
+		With(vault.PasswordModificationTimeField, vault.Timestamp(changed)).
+		With(vault.PasswordHistoryField, vault.SecretList{
+			vault.List{vault.Timestamp(changed), sensitive.String("hunter1")},
+		})
+}
+
+func TestValue(t *testing.T) {
+	e := testEntry()
+
+	testCases := []struct {
+		field    string
+		reveal   bool
+		expected string
+	}{
+		{vault.NameField, false, "Bank"},
+		{vault.PasswordField, false, sensitive.Redacted},
+		{vault.PasswordField, true, "hunter2"},
+		{vault.ProtectedField, false, "true"},
+		{vault.TotpDigitsField, false, "6"},
+		{vault.KeyboardShortcutField, false, "4106"},
+		{vault.PasswordExpiryIntervalField, false, "48h0m0s"},
+		{vault.PasswordHistoryField, false, "[2023-03-19 14:42:19, **********]"},
+		{vault.PasswordHistoryField, true, "[2023-03-19 14:42:19, hunter1]"},
+		{vault.NoteField, false, ""},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, Value(e.Get(tc.field), tc.reveal), tc.field)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var b bytes.Buffer
+	assert.Nil(t, JSON(&b, []vault.Entry{testEntry()}, false))
+
+	assert.JSONEq(t, `[{
+		"id": "123",
+		"name": "Bank",
+		"username": "luke",
+		"password": "**********",
+		"protected": true,
+		"totpDigits": 6,
+		"entryKeyboardShortcut": "4106",
+		"passwordExpiryInterval": "48h0m0s",
+		"passwordModificationTime": "2023-03-19T14:42:19Z",
+		"passwordHistory": [["2023-03-19T14:42:19Z", "**********"]]
+	}]`, b.String())
+}
+
+func TestJSON_empty(t *testing.T) {
+	var b bytes.Buffer
+	assert.Nil(t, JSON(&b, nil, false))
+	assert.Equal(t, "[]\n", b.String())
+}
+
+func TestCSV(t *testing.T) {
+	entries := []vault.Entry{
+		vault.NewEntry().WithId("1").WithName("Bank, Inc.").WithPassword("hunter2").WithProtected(true),
+		vault.NewEntry().WithId("2").WithName("Cantina").WithUrl("https://cantina.example.com/"),
+	}
+
+	var b bytes.Buffer
+	assert.Nil(t, CSV(&b, entries, true))
+	assert.Equal(t, "id,name,url,password,protected\n"+
+		"1,\"Bank, Inc.\",,hunter2,true\n"+
+		"2,Cantina,https://cantina.example.com/,,\n", b.String())
+}
+
+func TestTable(t *testing.T) {
+	entries := []vault.Entry{
+		vault.NewEntry().WithId("1").WithName("Bank").WithPassword("hunter2"),
+		vault.NewEntry().WithId("2").WithName("Cantina"),
+	}
+
+	var b bytes.Buffer
+	assert.Nil(t, Table(&b, entries, false))
+	assert.Equal(t, "ID  NAME     PASSWORD\n"+
+		"1   Bank     **********\n"+
+		"2   Cantina  \n", b.String())
+}
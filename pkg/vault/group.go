@@ -0,0 +1,167 @@
+package vault
+
+import (
+	"sort"
+	"strings"
+)
+
+// GroupPath is a location in a group hierarchy, with one element per level. The root of the
+// hierarchy is represented by an empty GroupPath.
+type GroupPath []string
+
+// ParseGroupPath parses a group in the format used by Entry.Group.
+func ParseGroupPath(s string) GroupPath {
+	return DefaultGroupPathSyntax.Parse(s)
+}
+
+// String formats the path in the format used by Entry.Group.
+func (p GroupPath) String() string {
+	return DefaultGroupPathSyntax.Format(p)
+}
+
+// Equal reports whether p and other refer to the same group.
+func (p GroupPath) Equal(other GroupPath) bool {
+	if len(p) != len(other) {
+		return false
+	}
+	for i := range p {
+		if p[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// HasPrefix reports whether p is the same group as prefix or one of its descendants.
+func (p GroupPath) HasPrefix(prefix GroupPath) bool {
+	return len(p) >= len(prefix) && p[:len(prefix)].Equal(prefix)
+}
+
+// GroupPathSyntax describes how a group hierarchy is flattened into a single string, as a list of
+// levels divided by Separator. Occurrences of Separator or Escape within a level are preceded by
+// Escape.
+type GroupPathSyntax struct {
+	Separator rune
+	Escape    rune
+}
+
+// DefaultGroupPathSyntax is the syntax used by Entry.Group.
+var DefaultGroupPathSyntax = GroupPathSyntax{Separator: '/', Escape: '\\'}
+
+// Parse splits s into its levels and removes any escaping. An Escape at the very end of s is
+// treated as a literal character.
+func (g GroupPathSyntax) Parse(s string) GroupPath {
+	if s == "" {
+		return nil
+	}
+
+	var p GroupPath
+	var level strings.Builder
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			level.WriteRune(r)
+			escaped = false
+		case r == g.Escape:
+			escaped = true
+		case r == g.Separator:
+			p = append(p, level.String())
+			level.Reset()
+		default:
+			level.WriteRune(r)
+		}
+	}
+	if escaped {
+		level.WriteRune(g.Escape)
+	}
+
+	return append(p, level.String())
+}
+
+// Format joins the levels of p, escaping them as needed.
+func (g GroupPathSyntax) Format(p GroupPath) string {
+	levels := make([]string, len(p))
+	for i, level := range p {
+		var b strings.Builder
+		for _, r := range level {
+			if r == g.Separator || r == g.Escape {
+				b.WriteRune(g.Escape)
+			}
+			b.WriteRune(r)
+		}
+		levels[i] = b.String()
+	}
+	return strings.Join(levels, string(g.Separator))
+}
+
+// GroupNode is a group in a tree built by BuildGroupTree.
+type GroupNode struct {
+	Name    string
+	Path    GroupPath
+	Groups  []*GroupNode
+	Entries []Entry
+}
+
+// BuildGroupTree arranges entries into a tree according to their groups. Groups listed in
+// emptyGroups are included in the tree even if they contain no entries.
+//
+// Subgroups are sorted by name, and entries are sorted by name and then username.
+func BuildGroupTree(entries []Entry, emptyGroups ...GroupPath) *GroupNode {
+	root := &GroupNode{}
+	for _, g := range emptyGroups {
+		root.add(g)
+	}
+	for _, e := range entries {
+		n := root.add(e.GroupPath())
+		n.Entries = append(n.Entries, e)
+	}
+	root.sort()
+	return root
+}
+
+// Find returns the node for the given group, or nil if it is not part of the tree.
+func (n *GroupNode) Find(path GroupPath) *GroupNode {
+	for _, level := range path {
+		if n = n.child(level); n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+func (n *GroupNode) add(path GroupPath) *GroupNode {
+	for i, level := range path {
+		next := n.child(level)
+		if next == nil {
+			next = &GroupNode{Name: level, Path: append(GroupPath(nil), path[:i+1]...)}
+			n.Groups = append(n.Groups, next)
+		}
+		n = next
+	}
+	return n
+}
+
+func (n *GroupNode) child(name string) *GroupNode {
+	for _, g := range n.Groups {
+		if g.Name == name {
+			return g
+		}
+	}
+	return nil
+}
+
+func (n *GroupNode) sort() {
+	sort.Slice(n.Groups, func(i, j int) bool {
+		return n.Groups[i].Name < n.Groups[j].Name
+	})
+	sort.Slice(n.Entries, func(i, j int) bool {
+		if n.Entries[i].Name() == n.Entries[j].Name() {
+			return n.Entries[i].Username() < n.Entries[j].Username()
+		}
+		return n.Entries[i].Name() < n.Entries[j].Name()
+	})
+	for _, g := range n.Groups {
+		g.sort()
+	}
+}
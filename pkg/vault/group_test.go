@@ -0,0 +1,102 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGroupPath(t *testing.T) {
+	testCases := []struct {
+		group    string
+		expected GroupPath
+	}{
+		{"", nil},
+		{"foo", GroupPath{"foo"}},
+		{"foo/bar", GroupPath{"foo", "bar"}},
+		{"foo/bar/baz", GroupPath{"foo", "bar", "baz"}},
+		{`foo\/bar`, GroupPath{"foo/bar"}},
+		{`foo\\/bar`, GroupPath{`foo\`, "bar"}},
+		{`foo.bar/baz`, GroupPath{"foo.bar", "baz"}},
+		{`foo\`, GroupPath{`foo\`}},
+		{`/foo/`, GroupPath{"", "foo", ""}},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, ParseGroupPath(tc.group), tc.group)
+	}
+}
+
+func TestGroupPath_String(t *testing.T) {
+	testCases := []struct {
+		path     GroupPath
+		expected string
+	}{
+		{nil, ""},
+		{GroupPath{"foo"}, "foo"},
+		{GroupPath{"foo", "bar"}, "foo/bar"},
+		{GroupPath{"foo/bar"}, `foo\/bar`},
+		{GroupPath{`foo\`, "bar"}, `foo\\/bar`},
+		{GroupPath{"foo.bar", "baz"}, "foo.bar/baz"},
+	}
+
+	for _, tc := range testCases {
+		actual := tc.path.String()
+		assert.Equal(t, tc.expected, actual)
+		assert.Equal(t, tc.path, ParseGroupPath(actual))
+	}
+}
+
+func TestGroupPathSyntax(t *testing.T) {
+	pwsafe := GroupPathSyntax{Separator: '.', Escape: '\\'}
+
+	assert.Equal(t, GroupPath{"Misc", "1/2"}, pwsafe.Parse("Misc.1/2"))
+	assert.Equal(t, GroupPath{"a.b", "c"}, pwsafe.Parse(`a\.b.c`))
+	assert.Equal(t, `a\.b.c`, pwsafe.Format(GroupPath{"a.b", "c"}))
+	assert.Equal(t, `Misc/1\/2`, pwsafe.Parse("Misc.1/2").String())
+}
+
+func TestGroupPath_HasPrefix(t *testing.T) {
+	p := GroupPath{"foo", "bar"}
+
+	assert.True(t, p.HasPrefix(nil))
+	assert.True(t, p.HasPrefix(GroupPath{"foo"}))
+	assert.True(t, p.HasPrefix(GroupPath{"foo", "bar"}))
+	assert.False(t, p.HasPrefix(GroupPath{"fo"}))
+	assert.False(t, p.HasPrefix(GroupPath{"foo", "bar", "baz"}))
+	assert.False(t, p.HasPrefix(GroupPath{"bar"}))
+}
+
+func TestBuildGroupTree(t *testing.T) {
+	entries := []Entry{
+		NewEntry().WithId("1").WithName("b").WithGroup("Prod/Web"),
+		NewEntry().WithId("2").WithName("a").WithGroup("Prod/Web"),
+		NewEntry().WithId("3").WithName("c").WithGroup("Prod"),
+		NewEntry().WithId("4").WithName("d"),
+		NewEntry().WithId("5").WithName("e").WithGroup("Dev"),
+	}
+
+	root := BuildGroupTree(entries, GroupPath{"Prod", "Empty"}, GroupPath{"Archive"})
+
+	assert.Len(t, root.Entries, 1)
+	assert.Equal(t, "4", root.Entries[0].Id())
+	assert.Len(t, root.Groups, 3)
+	assert.Equal(t, "Archive", root.Groups[0].Name)
+	assert.Equal(t, "Dev", root.Groups[1].Name)
+	assert.Equal(t, "Prod", root.Groups[2].Name)
+
+	prod := root.Find(GroupPath{"Prod"})
+	assert.Len(t, prod.Entries, 1)
+	assert.Len(t, prod.Groups, 2)
+	assert.Equal(t, GroupPath{"Prod", "Empty"}, prod.Groups[0].Path)
+	assert.Empty(t, prod.Groups[0].Entries)
+
+	web := root.Find(GroupPath{"Prod", "Web"})
+	assert.Equal(t, GroupPath{"Prod", "Web"}, web.Path)
+	assert.Len(t, web.Entries, 2)
+	assert.Equal(t, "2", web.Entries[0].Id())
+	assert.Equal(t, "1", web.Entries[1].Id())
+
+	assert.Nil(t, root.Find(GroupPath{"Prod", "Missing"}))
+	assert.Same(t, root, root.Find(nil))
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
+	"unicode"
 
 	"notpass-go/pkg/vault"
 )
@@ -40,6 +42,23 @@ func Or(conditions ...Condition) Condition {
 	}
 }
 
+func Not(condition Condition) Condition {
+	return func(e vault.Entry) bool {
+		return !condition(e)
+	}
+}
+
+// InGroup matches entries in the specified group. If recursive is true, entries in any of the
+// group's descendants also match.
+func InGroup(path vault.GroupPath, recursive bool) Condition {
+	return func(e vault.Entry) bool {
+		if recursive {
+			return e.GroupPath().HasPrefix(path)
+		}
+		return e.GroupPath().Equal(path)
+	}
+}
+
 type field struct {
 	name string
 }
@@ -48,23 +67,110 @@ func Where(name string) field {
 	return field{name}
 }
 
+// Equals matches entries where the field is exactly value.
+//
+// Like the other string predicates, Equals treats a missing field as if it contained the empty
+// string.
 func (f field) Equals(value string) Condition {
 	return func(e vault.Entry) bool {
-		return e.Get(f.name).AsString() == value
+		return f.stringValue(e) == value
+	}
+}
+
+func (f field) EqualsFold(value string) Condition {
+	return func(e vault.Entry) bool {
+		return strings.EqualFold(f.stringValue(e), value)
 	}
 }
 
 func (f field) Contains(value string) Condition {
 	return func(e vault.Entry) bool {
-		return strings.Contains(e.Get(f.name).AsString(), value)
+		return strings.Contains(f.stringValue(e), value)
+	}
+}
+
+// ContainsFold matches entries where the field contains value, under the same Unicode case
+// folding as EqualsFold, so e.g. "K" matches the Kelvin sign and "s" matches "ſ".
+func (f field) ContainsFold(value string) Condition {
+	folded := foldCase(value)
+	return func(e vault.Entry) bool {
+		return strings.Contains(foldCase(f.stringValue(e)), folded)
 	}
 }
 
+// foldCase maps every rune in s to the smallest rune it is equivalent to under simple Unicode
+// case folding, which is what strings.EqualFold compares.
+func foldCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		min := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < min {
+				min = f
+			}
+		}
+		return min
+	}, s)
+}
+
 func (f field) MatchesWildcard(pattern string) Condition {
 	r := wildcardPatternToRegex(pattern)
 	return func(e vault.Entry) bool {
-		return r.MatchString(e.Get(f.name).AsString())
+		return r.MatchString(f.stringValue(e))
+	}
+}
+
+func (f field) Matches(r *regexp.Regexp) Condition {
+	return func(e vault.Entry) bool {
+		return r.MatchString(f.stringValue(e))
+	}
+}
+
+func (f field) Exists() Condition {
+	return func(e vault.Entry) bool {
+		return e.Get(f.name) != nil
+	}
+}
+
+func (f field) Missing() Condition {
+	return Not(f.Exists())
+}
+
+// Before matches entries where the field is a vault.Timestamp earlier than t.
+//
+// Like the other timestamp predicates, Before never matches an entry where the field is missing
+// or holds some other type of value.
+func (f field) Before(t time.Time) Condition {
+	return func(e vault.Entry) bool {
+		ts, ok := f.timestampValue(e)
+		return ok && ts.Before(t)
+	}
+}
+
+func (f field) After(t time.Time) Condition {
+	return func(e vault.Entry) bool {
+		ts, ok := f.timestampValue(e)
+		return ok && ts.After(t)
+	}
+}
+
+// Within matches timestamps in the closed interval [start, end].
+func (f field) Within(start, end time.Time) Condition {
+	return func(e vault.Entry) bool {
+		ts, ok := f.timestampValue(e)
+		return ok && !ts.Before(start) && !ts.After(end)
+	}
+}
+
+func (f field) stringValue(e vault.Entry) string {
+	if v := e.Get(f.name); v != nil {
+		return v.AsString()
 	}
+	return ""
+}
+
+func (f field) timestampValue(e vault.Entry) (time.Time, bool) {
+	ts, ok := e.Get(f.name).(vault.Timestamp)
+	return time.Time(ts), ok
 }
 
 func wildcardPatternToRegex(pattern string) *regexp.Regexp {
@@ -1,7 +1,9 @@
 package query
 
 import (
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -154,3 +156,150 @@ func Test_wildcardPatternToRegexPattern(t *testing.T) {
 		assert.Equal(t, tc.expectedRegex, wildcardPatternToRegexPattern(tc.wildcard))
 	}
 }
+
+func TestNot(t *testing.T) {
+	assert.False(t, Not(Any)(vault.NewEntry()))
+	assert.True(t, Not(None)(vault.NewEntry()))
+}
+
+func TestProperty_missingField(t *testing.T) {
+	e := vault.NewEntry().With("bar", vault.String("baz"))
+
+	assert.True(t, Where("foo").Equals("")(e))
+	assert.False(t, Where("foo").Equals("baz")(e))
+	assert.True(t, Where("foo").Contains("")(e))
+	assert.False(t, Where("foo").ContainsFold("baz")(e))
+	assert.False(t, Where("foo").EqualsFold("baz")(e))
+	assert.False(t, Where("foo").MatchesWildcard("b*")(e))
+	assert.False(t, Where("foo").Matches(regexp.MustCompile("b"))(e))
+	assert.False(t, Where("foo").Before(time.Now())(e))
+	assert.False(t, Where("foo").After(time.Time{})(e))
+	assert.False(t, Where("foo").Within(time.Time{}, time.Now())(e))
+}
+
+func TestProperty_EqualsFold(t *testing.T) {
+	testCases := []struct {
+		e        vault.Entry
+		expected bool
+	}{
+		{vault.NewEntry().With("foo", vault.String("bar")), true},
+		{vault.NewEntry().With("foo", vault.String("BAR")), true},
+		{vault.NewEntry().With("foo", vault.String("bAr")), true},
+
+		{vault.NewEntry().With("foo", vault.String("baz")), false},
+		{vault.NewEntry().With("foo", vault.String("")), false},
+		{vault.NewEntry().With("foo", vault.String("BBAR")), false},
+	}
+
+	c := Where("foo").EqualsFold("Bar")
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, c(tc.e))
+	}
+}
+
+func TestProperty_ContainsFold(t *testing.T) {
+	testCases := []struct {
+		e        vault.Entry
+		expected bool
+	}{
+		{vault.NewEntry().With("foo", vault.String("bar")), true},
+		{vault.NewEntry().With("foo", vault.String("BBAR")), true},
+		{vault.NewEntry().With("foo", vault.String("bArR")), true},
+
+		{vault.NewEntry().With("foo", vault.String("baz")), false},
+		{vault.NewEntry().With("foo", vault.String("")), false},
+	}
+
+	c := Where("foo").ContainsFold("Bar")
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, c(tc.e))
+	}
+}
+
+func TestProperty_ContainsFold_unicode(t *testing.T) {
+	testCases := []struct {
+		e        vault.Entry
+		expected bool
+	}{
+		// U+212A KELVIN SIGN folds to k, and U+017F LATIN SMALL LETTER LONG S to s.
+		{vault.NewEntry().With("foo", vault.String("\u212aey ſafe")), true},
+		{vault.NewEntry().With("foo", vault.String("KEY SAFE")), true},
+		{vault.NewEntry().With("foo", vault.String("ΚΕΥ SAFE")), false},
+	}
+
+	c := Where("foo").ContainsFold("key safe")
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, c(tc.e))
+	}
+}
+
+func TestProperty_Matches(t *testing.T) {
+	testCases := []struct {
+		e        vault.Entry
+		expected bool
+	}{
+		{vault.NewEntry().With("foo", vault.String("bar")), true},
+		{vault.NewEntry().With("foo", vault.String("baaar")), true},
+
+		{vault.NewEntry().With("foo", vault.String("br")), false},
+		{vault.NewEntry().With("foo", vault.String("bars")), false},
+	}
+
+	c := Where("foo").Matches(regexp.MustCompile("^ba+r$"))
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, c(tc.e))
+	}
+}
+
+func TestProperty_ExistsAndMissing(t *testing.T) {
+	present := vault.NewEntry().With("foo", vault.String(""))
+	absent := vault.NewEntry().With("bar", vault.String("foo"))
+
+	assert.True(t, Where("foo").Exists()(present))
+	assert.False(t, Where("foo").Exists()(absent))
+	assert.False(t, Where("foo").Missing()(present))
+	assert.True(t, Where("foo").Missing()(absent))
+}
+
+func TestProperty_timestamps(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	e := vault.NewEntry().
+		With("foo", vault.Timestamp(now.AddDate(0, 0, -200))).
+		With("bar", vault.String(now.AddDate(0, 0, -200).Format(vault.DefaultTimeFormat)))
+
+	assert.True(t, Where("foo").Before(now.AddDate(0, 0, -180))(e))
+	assert.False(t, Where("foo").Before(now.AddDate(0, 0, -365))(e))
+	assert.True(t, Where("foo").After(now.AddDate(0, 0, -365))(e))
+	assert.False(t, Where("foo").After(now)(e))
+	assert.True(t, Where("foo").Within(now.AddDate(0, 0, -200), now)(e))
+	assert.False(t, Where("foo").Within(now.AddDate(0, 0, -199), now)(e))
+
+	assert.False(t, Where("bar").Before(now)(e))
+	assert.False(t, Where("bar").After(time.Time{})(e))
+	assert.False(t, Where("bar").Within(time.Time{}, now)(e))
+}
+
+func TestInGroup(t *testing.T) {
+	testCases := []struct {
+		group     string
+		recursive bool
+		expected  bool
+	}{
+		{"Prod", false, true},
+		{"Prod", true, true},
+		{"Prod/Web", false, false},
+		{"Prod/Web", true, true},
+		{"Production", true, false},
+		{"Dev/Prod", true, false},
+		{"", true, false},
+	}
+
+	for _, tc := range testCases {
+		c := InGroup(vault.GroupPath{"Prod"}, tc.recursive)
+		assert.Equal(t, tc.expected, c(vault.NewEntry().WithGroup(tc.group)), tc.group)
+	}
+}
@@ -0,0 +1,149 @@
+package runcmd
+
+import (
+	"fmt"
+	"strings"
+
+	"notpass-go/pkg/vault"
+)
+
+// Expand converts an entry's run command into the arguments of a process to run.
+//
+// The command is split into arguments at spaces, except where they are enclosed in double or
+// single quotes, before variables are expanded. So a value that contains spaces or quotes is always
+// passed as part of a single argument, and the arguments are never interpreted by a shell.
+//
+// Variables are written as $NAME or ${NAME}, and $$ is a literal dollar sign:
+//
+//	$g, $group      group
+//	$t, $title      name (title)
+//	$u, $username   username
+//	$p, $password   password
+//	$n, $notes      note
+//	$url            URL
+//	$autotype       autotype field
+func Expand(e vault.Entry) ([]string, error) {
+	return ExpandCommand(e, e.RunCommand())
+}
+
+// ExpandCommand converts command into arguments, using e's fields for its variables. See Expand.
+func ExpandCommand(e vault.Entry, command string) ([]string, error) {
+	words, err := split(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty run command")
+	}
+
+	args := make([]string, len(words))
+	for i, w := range words {
+		args[i], err = expandVariables(e, w)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+// split splits s into words at unquoted spaces and tabs, and removes the quotes.
+func split(s string) ([]string, error) {
+	var words []string
+	var w strings.Builder
+	inWord := false
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			w.WriteByte(c)
+		case c == '"' || c == '\'':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, w.String())
+				w.Reset()
+				inWord = false
+			}
+		default:
+			w.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, w.String())
+	}
+	return words, nil
+}
+
+func expandVariables(e vault.Entry, s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+
+		var name string
+		switch {
+		case i < len(s) && s[i] == '$':
+			b.WriteByte('$')
+			continue
+		case i < len(s) && s[i] == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end == -1 {
+				return "", fmt.Errorf("unterminated ${")
+			}
+			name = s[i+1 : i+end]
+			i += end
+		default:
+			j := i
+			for j < len(s) && isLetter(s[j]) {
+				j++
+			}
+			name = s[i:j]
+			i = j - 1
+		}
+
+		f, ok := variables[name]
+		if !ok {
+			return "", fmt.Errorf("unknown variable: $%s", name)
+		}
+		b.WriteString(f(e))
+	}
+	return b.String(), nil
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+var variables = map[string]func(vault.Entry) string{
+	"g":        vault.Entry.Group,
+	"group":    vault.Entry.Group,
+	"t":        vault.Entry.Name,
+	"title":    vault.Entry.Name,
+	"u":        vault.Entry.Username,
+	"username": vault.Entry.Username,
+	"p":        password,
+	"password": password,
+	"n":        note,
+	"notes":    note,
+	"url":      vault.Entry.Url,
+	"autotype": vault.Entry.Autotype,
+}
+
+func password(e vault.Entry) string {
+	return e.Password().AsString()
+}
+
+func note(e vault.Entry) string {
+	return e.Note().AsString()
+}
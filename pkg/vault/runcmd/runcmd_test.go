@@ -0,0 +1,43 @@
+package runcmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/pkg/vault"
+)
+
+func TestExpandCommand(t *testing.T) {
+	e := vault.NewEntry().
+		WithGroup("Servers").
+		WithName("Mos Eisley").
+		WithUsername("luke").
+		WithPassword(`hunter2 "; rm -rf ~`).
+		WithUrl("cantina.example.com")
+
+	testCases := []struct {
+		command  string
+		expected []string
+	}{
+		{"ssh $u@$url", []string{"ssh", "luke@cantina.example.com"}},
+		{"ssh ${username}@${url}", []string{"ssh", "luke@cantina.example.com"}},
+		{"  sshpass  -p $p\tssh $u@$url ", []string{"sshpass", "-p", `hunter2 "; rm -rf ~`, "ssh", "luke@cantina.example.com"}},
+		{`echo "$g/$t" 'single quoted' costs$$5`, []string{"echo", "Servers/Mos Eisley", "single quoted", "costs$5"}},
+		{`notify "" ${title}x`, []string{"notify", "", "Mos Eisleyx"}},
+		{`cmd /d /c dir c:`, []string{"cmd", "/d", "/c", "dir", "c:"}},
+	}
+
+	for _, tc := range testCases {
+		args, err := ExpandCommand(e, tc.command)
+		assert.Nil(t, err, tc.command)
+		assert.Equal(t, tc.expected, args, tc.command)
+	}
+}
+
+func TestExpandCommand_errors(t *testing.T) {
+	for _, command := range []string{"", "   ", `echo "unterminated`, "echo $unknown", "echo $", "echo ${url"} {
+		_, err := ExpandCommand(vault.NewEntry(), command)
+		assert.NotNil(t, err, command)
+	}
+}
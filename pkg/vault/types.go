@@ -0,0 +1,132 @@
+package vault
+
+import (
+	"fmt"
+
+	"notpass-go/pkg/sensitive"
+)
+
+// Type identifies the kind of a field's Value, so that backends and formatters agree on how each
+// field is decoded and rendered.
+type Type int
+
+const (
+	StringType Type = iota
+	SecretStringType
+	BoolType
+	IntType
+	BytesType
+	SecretBytesType
+	TimestampType
+	DurationType
+	ListType
+	SecretListType
+)
+
+var typeNames = map[Type]string{
+	StringType:       "string",
+	SecretStringType: "secretString",
+	BoolType:         "bool",
+	IntType:          "int",
+	BytesType:        "bytes",
+	SecretBytesType:  "secretBytes",
+	TimestampType:    "timestamp",
+	DurationType:     "duration",
+	ListType:         "list",
+	SecretListType:   "secretList",
+}
+
+func (t Type) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
+// IsSecret reports whether values of the type are secrets.
+func (t Type) IsSecret() bool {
+	return t == SecretStringType || t == SecretBytesType || t == SecretListType
+}
+
+// TypeOf returns the type of v. Values of types this package doesn't know are treated as strings,
+// or secret strings if they are secrets.
+func TypeOf(v Value) Type {
+	switch v.(type) {
+	case String:
+		return StringType
+	case sensitive.String:
+		return SecretStringType
+	case Bool:
+		return BoolType
+	case Int:
+		return IntType
+	case Bytes:
+		return BytesType
+	case sensitive.Bytes:
+		return SecretBytesType
+	case Timestamp:
+		return TimestampType
+	case Duration:
+		return DurationType
+	case List:
+		return ListType
+	case SecretList:
+		return SecretListType
+	case Secret:
+		return SecretStringType
+	default:
+		return StringType
+	}
+}
+
+// fieldTypes are the types of the well-known fields. Backends decode them to these types, and
+// keep undecodable values of secret fields as secrets.
+var fieldTypes = map[string]Type{
+	GroupField:    StringType,
+	IdField:       StringType,
+	NameField:     StringType,
+	NoteField:     SecretStringType,
+	PasswordField: SecretStringType,
+	UrlField:      StringType,
+	UsernameField: StringType,
+
+	CreationTimeField:             TimestampType,
+	LastAccessTimeField:           TimestampType,
+	LastModificationTimeField:     TimestampType,
+	PasswordExpiryIntervalField:   DurationType,
+	PasswordExpiryTimeField:       TimestampType,
+	PasswordModificationTimeField: TimestampType,
+	PasswordHistoryField:          SecretListType,
+	PasswordPolicyField:           StringType,
+	PasswordPolicyNameField:       StringType,
+	PasswordSymbolsField:          StringType,
+
+	AutotypeField:               StringType,
+	RunCommandField:             StringType,
+	DoubleClickActionField:      StringType,
+	ShiftDoubleClickActionField: StringType,
+	KeyboardShortcutField:       BytesType,
+
+	EmailField:            StringType,
+	ProtectedField:        BoolType,
+	CreditCardNumberField: SecretStringType,
+	CreditCardExpiryField: SecretStringType,
+	CreditCardCVVField:    SecretStringType,
+	CreditCardPINField:    SecretStringType,
+	QRCodeField:           SecretStringType,
+
+	AliasOfField:    StringType,
+	ShortcutOfField: StringType,
+
+	TwoFactorKeyField:  SecretStringType,
+	TotpAlgorithmField: StringType,
+	TotpDigitsField:    IntType,
+	TotpPeriodField:    DurationType,
+	TotpStartTimeField: TimestampType,
+}
+
+// FieldType returns the type of a well-known field's values.
+func FieldType(name string) (Type, bool) {
+	t, ok := fieldTypes[name]
+	return t, ok
+}
@@ -0,0 +1,42 @@
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"notpass-go/pkg/sensitive"
+)
+
+func TestTypeOf(t *testing.T) {
+	testCases := []struct {
+		value    Value
+		expected Type
+	}{
+		{String("foo"), StringType},
+		{sensitive.String("foo"), SecretStringType},
+		{Bool(true), BoolType},
+		{Int(8), IntType},
+		{Bytes{0x01}, BytesType},
+		{sensitive.Bytes{0x01}, SecretBytesType},
+		{Timestamp(time.Now()), TimestampType},
+		{Duration(time.Second), DurationType},
+		{List{String("foo")}, ListType},
+		{SecretList{sensitive.String("foo")}, SecretListType},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, TypeOf(tc.value), tc.expected.String())
+		assert.Equal(t, tc.expected.IsSecret(), NewEntry().With("f", tc.value).WithoutSecrets().Get("f") == nil, tc.expected.String())
+	}
+}
+
+func TestFieldType(t *testing.T) {
+	typ, ok := FieldType(PasswordHistoryField)
+	assert.True(t, ok)
+	assert.Equal(t, SecretListType, typ)
+
+	_, ok = FieldType("custom")
+	assert.False(t, ok)
+}
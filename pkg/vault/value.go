@@ -1,7 +1,12 @@
 package vault
 
 import (
+	"encoding/hex"
+	"strconv"
+	"strings"
 	"time"
+
+	"notpass-go/pkg/sensitive"
 )
 
 var DefaultTimeFormat = "2006-01-02 15:04:05"
@@ -22,8 +27,64 @@ func (s String) AsString() string {
 	return string(s)
 }
 
+// Bytes is binary data, such as a field a backend doesn't understand. AsString returns it in hex.
+type Bytes []byte
+
+func (b Bytes) AsString() string {
+	return hex.EncodeToString(b)
+}
+
+type Bool bool
+
+func (b Bool) AsString() string {
+	return strconv.FormatBool(bool(b))
+}
+
+type Int int64
+
+func (i Int) AsString() string {
+	return strconv.FormatInt(int64(i), 10)
+}
+
 type Timestamp time.Time
 
 func (t Timestamp) AsString() string {
 	return time.Time(t).Format(DefaultTimeFormat)
 }
+
+type Duration time.Duration
+
+func (d Duration) AsString() string {
+	return time.Duration(d).String()
+}
+
+// List is an ordered list of values. AsString joins them with a comma and a space.
+type List []Value
+
+func (l List) AsString() string {
+	return joinValues(l)
+}
+
+// SecretList is a List that contains secrets, such as a password history, so that it is removed
+// from entries along with the other secret fields.
+type SecretList []Value
+
+func (l SecretList) AsString() string {
+	return joinValues(l)
+}
+
+func (l SecretList) String() string {
+	return sensitive.Redacted
+}
+
+func (l SecretList) GoString() string {
+	return sensitive.Redacted
+}
+
+func joinValues(values []Value) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = v.AsString()
+	}
+	return strings.Join(s, ", ")
+}
@@ -29,6 +29,9 @@ type SearchableVault interface {
 // Put adds or replaces an entry.
 //
 // Delete removes an entry.
+//
+// Put and Delete must return ErrProtectedEntry, without changing the vault, if they would modify
+// or remove a protected entry (see Entry.CheckEditable).
 type WritableVault interface {
 	io.Closer
 	Put(id string, entry Entry) error